@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -11,8 +12,19 @@ import (
 	"time"
 
 	"github.com/KasumiMercury/primind-tasks/internal/api"
+	"github.com/KasumiMercury/primind-tasks/internal/attempts"
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	"github.com/KasumiMercury/primind-tasks/internal/blobstore"
 	"github.com/KasumiMercury/primind-tasks/internal/config"
+	"github.com/KasumiMercury/primind-tasks/internal/database"
+	"github.com/KasumiMercury/primind-tasks/internal/envelope"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+	"github.com/KasumiMercury/primind-tasks/internal/labels"
+	"github.com/KasumiMercury/primind-tasks/internal/observability/metrics"
 	"github.com/KasumiMercury/primind-tasks/internal/queue"
+	"github.com/KasumiMercury/primind-tasks/internal/schedule"
+	"github.com/KasumiMercury/primind-tasks/internal/workflow"
 )
 
 // Version is set via ldflags at build time
@@ -48,7 +60,101 @@ func run() error {
 
 	cfg := config.Load()
 
-	client := queue.NewClient(cfg)
+	var clientOpts []queue.ClientOption
+
+	keyring, err := envelope.LoadKeyring(cfg.PayloadEncryptionKeys, cfg.PayloadEncryptionPrimaryKey)
+	if err != nil {
+		slog.Error("failed to load payload encryption keys", slog.String("error", err.Error()))
+
+		return err
+	}
+	if keyring != nil {
+		clientOpts = append(clientOpts, queue.WithPayloadEncryption(keyring, cfg.PayloadEncryptedHeaders))
+	}
+
+	if cfg.PayloadCompression != "" {
+		if !queue.SupportedBodyEncoding(cfg.PayloadCompression) {
+			err := fmt.Errorf("unsupported PAYLOAD_COMPRESSION %q", cfg.PayloadCompression)
+			slog.Error("invalid payload compression", slog.String("error", err.Error()))
+
+			return err
+		}
+		clientOpts = append(clientOpts, queue.WithPayloadCompression(cfg.PayloadCompression, cfg.PayloadCompressionThreshold))
+	}
+
+	payloadStore, err := blobstore.NewFromConfig(cfg)
+	if err != nil {
+		slog.Error("failed to initialize payload store", slog.String("error", err.Error()))
+
+		return err
+	}
+	if payloadStore != nil {
+		clientOpts = append(clientOpts, queue.WithPayloadStore(payloadStore, cfg.PayloadStoreThreshold))
+	}
+
+	historyDB, err := history.OpenDB(cfg)
+	if err != nil {
+		slog.Error("failed to open history database", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	var historySink *history.Sink
+	if historyDB != nil {
+		historySink = history.NewSink(historyDB, cfg.HistoryBufferSize)
+
+		defer func() {
+			historySink.Close()
+
+			if err := database.Close(historyDB); err != nil {
+				slog.Warn("failed to close history database", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	if historySink != nil {
+		clientOpts = append(clientOpts, queue.WithHistory(historySink))
+	}
+
+	rdb := queue.NewRedisClient(cfg)
+
+	defer func() {
+		if err := rdb.Close(); err != nil {
+			slog.Warn("failed to close redis client", slog.String("error", err.Error()))
+		}
+	}()
+
+	var eventStream, eventReader *events.Stream
+	if cfg.EventStreamMaxLen > 0 {
+		eventStream = events.NewStream(rdb, int64(cfg.EventStreamMaxLen))
+		clientOpts = append(clientOpts, queue.WithEvents(eventStream))
+
+		// Open event streams block on their own connections, so they cannot
+		// drain the pool the API requests use
+		streamRDB := queue.NewRedisClientWithPoolSize(cfg, cfg.EventStreamMaxClients)
+
+		defer func() {
+			if err := streamRDB.Close(); err != nil {
+				slog.Warn("failed to close event stream redis client", slog.String("error", err.Error()))
+			}
+		}()
+
+		eventReader = events.NewStream(streamRDB, int64(cfg.EventStreamMaxLen))
+	}
+
+	clientOpts = append(clientOpts, queue.WithLabelIndex(labels.NewIndex(rdb)))
+
+	if len(cfg.DedupQueues) > 0 {
+		if !queue.SupportedDedupMode(cfg.DedupMode) {
+			err := fmt.Errorf("unsupported DEDUP_MODE %q, use %s or %s", cfg.DedupMode, queue.DedupReject, queue.DedupExisting)
+			slog.Error("invalid deduplication mode", slog.String("error", err.Error()))
+
+			return err
+		}
+		clientOpts = append(clientOpts, queue.WithDeduplication(cfg.DedupQueues, cfg.DedupWindow, cfg.DedupHeaders, cfg.DedupMode))
+	}
+
+	client := queue.NewClient(cfg, clientOpts...)
 
 	defer func() {
 		if err := client.Close(); err != nil {
@@ -56,7 +162,72 @@ func run() error {
 		}
 	}()
 
-	server := api.NewServer(cfg, client, Version)
+	if cfg.QueueMetricsEnabled {
+		collector, err := metrics.NewQueueCollector(client.Inspector(), cfg.QueueMetricsInterval)
+		if err != nil {
+			slog.Error("failed to initialize queue metrics", slog.String("error", err.Error()))
+
+			return err
+		}
+
+		defer func() {
+			if err := collector.Shutdown(); err != nil {
+				slog.Warn("failed to shutdown queue metrics", slog.String("error", err.Error()))
+			}
+		}()
+
+		go collector.Run(ctx)
+	}
+
+	httpMetrics, err := metrics.NewHTTPMetrics()
+	if err != nil {
+		slog.Error("failed to initialize HTTP metrics", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	serverOpts := []api.ServerOption{api.WithHTTPMetrics(httpMetrics)}
+
+	authenticator, err := newAuthenticator(cfg)
+	if err != nil {
+		slog.Error("failed to initialize authentication", slog.String("error", err.Error()))
+
+		return err
+	}
+	if authenticator != nil {
+		serverOpts = append(serverOpts, api.WithAuthenticator(authenticator))
+	} else {
+		slog.WarnContext(ctx, "authentication is disabled; API accepts unauthenticated requests",
+			slog.String("event", "auth.disabled"),
+		)
+	}
+
+	if cfg.AuthPolicyFile != "" {
+		policy, err := auth.LoadPolicy(cfg.AuthPolicyFile)
+		if err != nil {
+			slog.Error("failed to load authorization policy", slog.String("error", err.Error()))
+
+			return err
+		}
+		serverOpts = append(serverOpts, api.WithPolicy(policy))
+	}
+	if h := obs.MetricsHandler(); h != nil {
+		serverOpts = append(serverOpts, api.WithMetricsHandler(h))
+	}
+
+	serverOpts = append(serverOpts,
+		api.WithSchedules(schedule.NewStore(rdb)),
+		api.WithWorkflows(workflow.NewEngine(rdb, client, cfg.WorkflowRetention)),
+		api.WithAttemptHistory(attempts.NewStore(rdb, cfg.AttemptHistoryLimit, cfg.AttemptHistoryTTL)),
+	)
+	if historyDB != nil {
+		serverOpts = append(serverOpts, api.WithHistory(history.NewStore(historyDB)))
+	}
+	if eventReader != nil {
+		serverOpts = append(serverOpts, api.WithEvents(eventReader, cfg.EventStreamMaxClients))
+	}
+
+	server := api.NewServer(cfg, client, Version, serverOpts...)
 
 	slog.InfoContext(ctx, "starting API server",
 		slog.String("event", "server.start"),
@@ -96,3 +267,36 @@ func run() error {
 
 	return nil
 }
+
+// newAuthenticator builds the configured authenticators, or returns nil when
+// neither API keys nor a JWKS file is configured.
+func newAuthenticator(cfg *config.Config) (auth.Authenticator, error) {
+	var chain auth.Chain
+
+	if cfg.AuthAPIKeysFile != "" {
+		a, err := auth.LoadAPIKeys(cfg.AuthAPIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, a)
+	}
+
+	if cfg.AuthJWKSFile != "" {
+		a, err := auth.NewJWTAuthenticator(auth.JWTConfig{
+			JWKSFile:    cfg.AuthJWKSFile,
+			Issuer:      cfg.AuthJWTIssuer,
+			Audience:    cfg.AuthJWTAudience,
+			GroupsClaim: cfg.AuthJWTGroupsClaim,
+		})
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, a)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+
+	return chain, nil
+}
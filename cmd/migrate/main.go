@@ -0,0 +1,112 @@
+// Command migrate rewrites queued tasks whose payload still uses the legacy
+// JSON encoding into the versioned protobuf encoding.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/KasumiMercury/primind-tasks/internal/config"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+// Version is set via ldflags at build time
+var Version = "dev"
+
+func main() {
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	queues := flag.String("queues", "", "comma-separated queues to migrate (default: all queues)")
+	dryRun := flag.Bool("dry-run", false, "count legacy tasks without rewriting them")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	obs, err := initObservability(ctx)
+	if err != nil {
+		slog.Error("failed to initialize observability", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+
+		if err := obs.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("observability shutdown error", slog.String("error", err.Error()))
+		}
+	}()
+
+	slog.SetDefault(obs.Logger())
+
+	cfg := config.Load()
+
+	queueNames := splitList(*queues)
+	if len(queueNames) == 0 {
+		inspector := asynq.NewInspector(queue.RedisClientOpt(cfg))
+		queueNames, err = inspector.Queues()
+		_ = inspector.Close()
+		if err != nil {
+			slog.Error("failed to list queues", slog.String("error", err.Error()))
+
+			return err
+		}
+	}
+
+	rdb := queue.NewRedisClient(cfg)
+	defer func() {
+		if err := rdb.Close(); err != nil {
+			slog.Warn("failed to close redis client", slog.String("error", err.Error()))
+		}
+	}()
+
+	for _, name := range queueNames {
+		result, err := queue.MigrateLegacyPayloads(ctx, rdb, name, *dryRun)
+		if err != nil {
+			slog.ErrorContext(ctx, "payload migration failed",
+				slog.String("event", "migrate.fail"),
+				slog.String("queue", name),
+				slog.String("error", err.Error()),
+			)
+
+			return err
+		}
+
+		slog.InfoContext(ctx, "payload migration finished",
+			slog.String("event", "migrate.finish"),
+			slog.String("queue", name),
+			slog.Bool("dry_run", *dryRun),
+			slog.Int("scanned", result.Scanned),
+			slog.Int("migrated", result.Migrated),
+			slog.Int("conflicts", result.Conflicts),
+			slog.Int("failed", result.Failed),
+		)
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/KasumiMercury/primind-tasks/internal/blobstore"
+	"github.com/KasumiMercury/primind-tasks/internal/config"
+	"github.com/KasumiMercury/primind-tasks/internal/database"
+	"github.com/KasumiMercury/primind-tasks/internal/envelope"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+	"github.com/KasumiMercury/primind-tasks/internal/outbox"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+// Version is set via ldflags at build time
+var Version = "dev"
+
+func main() {
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	obs, err := initObservability(ctx)
+	if err != nil {
+		slog.Error("failed to initialize observability", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+
+		if err := obs.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("observability shutdown error", slog.String("error", err.Error()))
+		}
+	}()
+
+	slog.SetDefault(obs.Logger())
+
+	cfg := config.Load()
+
+	if cfg.OutboxDBDriver == "" || cfg.OutboxDBDSN == "" {
+		slog.Error("OUTBOX_DB_DRIVER and OUTBOX_DB_DSN environment variables are required")
+
+		return errors.New("OUTBOX_DB_DRIVER and OUTBOX_DB_DSN environment variables are required")
+	}
+
+	db, err := database.Open(cfg.OutboxDBDriver, cfg.OutboxDBDSN)
+	if err != nil {
+		slog.Error("failed to open outbox database", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	defer func() {
+		if err := database.Close(db); err != nil {
+			slog.Warn("failed to close outbox database", slog.String("error", err.Error()))
+		}
+	}()
+
+	if cfg.OutboxAutoMigrate {
+		if err := db.AutoMigrate(&outbox.Message{}); err != nil {
+			slog.Error("failed to migrate outbox table", slog.String("error", err.Error()))
+
+			return err
+		}
+	}
+
+	var clientOpts []queue.ClientOption
+
+	keyring, err := envelope.LoadKeyring(cfg.PayloadEncryptionKeys, cfg.PayloadEncryptionPrimaryKey)
+	if err != nil {
+		slog.Error("failed to load payload encryption keys", slog.String("error", err.Error()))
+
+		return err
+	}
+	if keyring != nil {
+		clientOpts = append(clientOpts, queue.WithPayloadEncryption(keyring, cfg.PayloadEncryptedHeaders))
+	}
+
+	if cfg.PayloadCompression != "" {
+		if !queue.SupportedBodyEncoding(cfg.PayloadCompression) {
+			err := fmt.Errorf("unsupported PAYLOAD_COMPRESSION %q", cfg.PayloadCompression)
+			slog.Error("invalid payload compression", slog.String("error", err.Error()))
+
+			return err
+		}
+		clientOpts = append(clientOpts, queue.WithPayloadCompression(cfg.PayloadCompression, cfg.PayloadCompressionThreshold))
+	}
+
+	payloadStore, err := blobstore.NewFromConfig(cfg)
+	if err != nil {
+		slog.Error("failed to initialize payload store", slog.String("error", err.Error()))
+
+		return err
+	}
+	if payloadStore != nil {
+		clientOpts = append(clientOpts, queue.WithPayloadStore(payloadStore, cfg.PayloadStoreThreshold))
+	}
+
+	historyDB, err := history.OpenDB(cfg)
+	if err != nil {
+		slog.Error("failed to open history database", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	if historyDB != nil {
+		historySink := history.NewSink(historyDB, cfg.HistoryBufferSize)
+		clientOpts = append(clientOpts, queue.WithHistory(historySink))
+
+		defer func() {
+			historySink.Close()
+
+			if err := database.Close(historyDB); err != nil {
+				slog.Warn("failed to close history database", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	if cfg.EventStreamMaxLen > 0 {
+		rdb := queue.NewRedisClient(cfg)
+
+		defer func() {
+			if err := rdb.Close(); err != nil {
+				slog.Warn("failed to close redis client", slog.String("error", err.Error()))
+			}
+		}()
+
+		clientOpts = append(clientOpts, queue.WithEvents(events.NewStream(rdb, int64(cfg.EventStreamMaxLen))))
+	}
+
+	client := queue.NewClient(cfg, clientOpts...)
+
+	defer func() {
+		if err := client.Close(); err != nil {
+			slog.Warn("failed to close queue client", slog.String("error", err.Error()))
+		}
+	}()
+
+	poller := outbox.NewPoller(db, client, cfg.OutboxPollInterval, cfg.OutboxBatchSize, cfg.OutboxMaxAttempts, cfg.OutboxTaskRetention)
+
+	slog.InfoContext(ctx, "starting outbox poller",
+		slog.String("event", "outbox.start"),
+		slog.String("driver", cfg.OutboxDBDriver),
+		slog.Duration("poll_interval", cfg.OutboxPollInterval),
+		slog.Int("batch_size", cfg.OutboxBatchSize),
+		slog.String("redis", cfg.RedisAddr),
+		slog.String("version", Version),
+	)
+
+	poller.Run(ctx)
+
+	slog.InfoContext(ctx, "outbox poller stopped",
+		slog.String("event", "outbox.stop"),
+	)
+
+	return nil
+}
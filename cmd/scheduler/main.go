@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/KasumiMercury/primind-tasks/internal/config"
+	"github.com/KasumiMercury/primind-tasks/internal/database"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+	"github.com/KasumiMercury/primind-tasks/internal/labels"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+	"github.com/KasumiMercury/primind-tasks/internal/schedule"
+)
+
+// Version is set via ldflags at build time
+var Version = "dev"
+
+func main() {
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	obs, err := initObservability(ctx)
+	if err != nil {
+		slog.Error("failed to initialize observability", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+
+		if err := obs.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("observability shutdown error", slog.String("error", err.Error()))
+		}
+	}()
+
+	slog.SetDefault(obs.Logger())
+
+	cfg := config.Load()
+
+	rdb := queue.NewRedisClient(cfg)
+
+	defer func() {
+		if err := rdb.Close(); err != nil {
+			slog.Warn("failed to close redis client", slog.String("error", err.Error()))
+		}
+	}()
+
+	historyDB, err := history.OpenDB(cfg)
+	if err != nil {
+		slog.Error("failed to open history database", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	var historySink *history.Sink
+	if historyDB != nil {
+		historySink = history.NewSink(historyDB, cfg.HistoryBufferSize)
+
+		defer func() {
+			historySink.Close()
+
+			if err := database.Close(historyDB); err != nil {
+				slog.Warn("failed to close history database", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	var eventStream *events.Stream
+	if cfg.EventStreamMaxLen > 0 {
+		eventStream = events.NewStream(rdb, int64(cfg.EventStreamMaxLen))
+	}
+
+	asynqClient := asynq.NewClient(queue.RedisClientOpt(cfg))
+
+	defer func() {
+		if err := asynqClient.Close(); err != nil {
+			slog.Warn("failed to close asynq client", slog.String("error", err.Error()))
+		}
+	}()
+
+	elector := schedule.NewLeaderElector(rdb, cfg.SchedulerLeaderTTL)
+
+	slog.InfoContext(ctx, "starting scheduler",
+		slog.String("event", "scheduler.start"),
+		slog.String("instance_id", elector.ID()),
+		slog.Duration("sync_interval", cfg.SchedulerSyncInterval),
+		slog.String("redis", cfg.RedisAddr),
+		slog.String("version", Version),
+	)
+
+	elector.Run(ctx, func(leaderCtx context.Context) {
+		// A leader that lost its lease notices within the lease TTL, so runs
+		// are fenced for that long
+		runner := schedule.NewRunner(
+			schedule.NewStore(rdb),
+			asynqClient,
+			cfg.RetryCount,
+			cfg.TaskResultRetention,
+			cfg.SchedulerLeaderTTL,
+			cfg.SchedulerSyncInterval,
+			logScheduledEnqueue(leaderCtx, historySink, eventStream, labels.NewIndex(rdb)),
+		)
+		runner.Run(leaderCtx)
+	})
+
+	slog.InfoContext(ctx, "scheduler stopped",
+		slog.String("event", "scheduler.stop"),
+	)
+
+	return nil
+}
+
+func logScheduledEnqueue(ctx context.Context, sink *history.Sink, stream *events.Stream, index *labels.Index) func(info *asynq.TaskInfo, err error) {
+	return func(info *asynq.TaskInfo, err error) {
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to enqueue scheduled task",
+				slog.String("event", "schedule.enqueue.fail"),
+				slog.String("error", err.Error()),
+			)
+
+			return
+		}
+
+		slog.InfoContext(ctx, "enqueued scheduled task",
+			slog.String("event", "schedule.enqueue"),
+			slog.String("queue", info.Queue),
+			slog.String("task_id", info.ID),
+		)
+
+		sink.Record(ctx, history.Event{
+			TaskID: info.ID,
+			Queue:  info.Queue,
+			Type:   history.EventCreated,
+		})
+		stream.Publish(ctx, events.Event{
+			Type:   events.TypeEnqueued,
+			TaskID: info.ID,
+			Queue:  info.Queue,
+		})
+
+		// The runner enqueues without the queue client, so the labels are
+		// indexed here, after the task was created
+		if payload, err := queue.UnmarshalTaskPayload(info.Payload); err == nil {
+			if err := index.Add(ctx, info.Queue, info.ID, payload.Labels); err != nil {
+				slog.WarnContext(ctx, "failed to index scheduled task labels",
+					slog.String("event", "schedule.labels.fail"),
+					slog.String("task_id", info.ID),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
@@ -3,14 +3,30 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/hibiken/asynq"
+
+	"github.com/KasumiMercury/primind-tasks/internal/attempts"
+	"github.com/KasumiMercury/primind-tasks/internal/blobstore"
 	"github.com/KasumiMercury/primind-tasks/internal/config"
+	"github.com/KasumiMercury/primind-tasks/internal/database"
+	"github.com/KasumiMercury/primind-tasks/internal/envelope"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+	"github.com/KasumiMercury/primind-tasks/internal/labels"
+	"github.com/KasumiMercury/primind-tasks/internal/observability/metrics"
+	"github.com/KasumiMercury/primind-tasks/internal/observability/tracing"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+	"github.com/KasumiMercury/primind-tasks/internal/webhook"
 	"github.com/KasumiMercury/primind-tasks/internal/worker"
+	"github.com/KasumiMercury/primind-tasks/internal/workflow"
 )
 
 // Version is set via ldflags at build time
@@ -52,7 +68,209 @@ func run() error {
 		return errors.New("TARGET_ENDPOINT environment variable is required")
 	}
 
-	server := worker.NewServer(cfg)
+	linkMode, err := tracing.ParseTaskLinkMode(cfg.TraceLinkMode)
+	if err != nil {
+		err = fmt.Errorf("invalid TRACE_LINK_MODE: %w", err)
+		slog.Error("invalid trace link mode", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	if cfg.QueueMetricsEnabled {
+		inspector := asynq.NewInspector(queue.RedisClientOpt(cfg))
+
+		defer func() {
+			if err := inspector.Close(); err != nil {
+				slog.Warn("failed to close queue inspector", slog.String("error", err.Error()))
+			}
+		}()
+
+		collector, err := metrics.NewQueueCollector(inspector, cfg.QueueMetricsInterval)
+		if err != nil {
+			slog.Error("failed to initialize queue metrics", slog.String("error", err.Error()))
+
+			return err
+		}
+
+		defer func() {
+			if err := collector.Shutdown(); err != nil {
+				slog.Warn("failed to shutdown queue metrics", slog.String("error", err.Error()))
+			}
+		}()
+
+		go collector.Run(ctx)
+	}
+
+	keyring, err := envelope.LoadKeyring(cfg.PayloadEncryptionKeys, cfg.PayloadEncryptionPrimaryKey)
+	if err != nil {
+		slog.Error("failed to load payload encryption keys", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	payloadStore, err := blobstore.NewFromConfig(cfg)
+	if err != nil {
+		slog.Error("failed to initialize payload store", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	historyDB, err := history.OpenDB(cfg)
+	if err != nil {
+		slog.Error("failed to open history database", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	var historySink *history.Sink
+	if historyDB != nil {
+		historySink = history.NewSink(historyDB, cfg.HistoryBufferSize)
+
+		defer func() {
+			historySink.Close()
+
+			if err := database.Close(historyDB); err != nil {
+				slog.Warn("failed to close history database", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	// Follow-up tasks and workflow nodes are enqueued with the same payload
+	// handling as the API
+	var clientOpts []queue.ClientOption
+	if keyring != nil {
+		clientOpts = append(clientOpts, queue.WithPayloadEncryption(keyring, cfg.PayloadEncryptedHeaders))
+	}
+	if cfg.PayloadCompression != "" {
+		if !queue.SupportedBodyEncoding(cfg.PayloadCompression) {
+			err := fmt.Errorf("unsupported PAYLOAD_COMPRESSION %q", cfg.PayloadCompression)
+			slog.Error("invalid payload compression", slog.String("error", err.Error()))
+
+			return err
+		}
+		clientOpts = append(clientOpts, queue.WithPayloadCompression(cfg.PayloadCompression, cfg.PayloadCompressionThreshold))
+	}
+	if payloadStore != nil {
+		clientOpts = append(clientOpts, queue.WithPayloadStore(payloadStore, cfg.PayloadStoreThreshold))
+	}
+
+	if historySink != nil {
+		clientOpts = append(clientOpts, queue.WithHistory(historySink))
+	}
+
+	rdb := queue.NewRedisClient(cfg)
+
+	defer func() {
+		if err := rdb.Close(); err != nil {
+			slog.Warn("failed to close redis client", slog.String("error", err.Error()))
+		}
+	}()
+
+	var eventStream *events.Stream
+	if cfg.EventStreamMaxLen > 0 {
+		eventStream = events.NewStream(rdb, int64(cfg.EventStreamMaxLen))
+		clientOpts = append(clientOpts, queue.WithEvents(eventStream))
+	}
+
+	labelIndex := labels.NewIndex(rdb)
+	clientOpts = append(clientOpts, queue.WithLabelIndex(labelIndex))
+
+	followUps := queue.NewClient(cfg, clientOpts...)
+
+	defer func() {
+		if err := followUps.Close(); err != nil {
+			slog.Warn("failed to close queue client", slog.String("error", err.Error()))
+		}
+	}()
+
+	if payloadStore != nil && cfg.PayloadStoreCollectInterval > 0 {
+		go followUps.RunBodyCollector(ctx, cfg.PayloadStoreCollectInterval)
+	}
+
+	var webhookSigner *webhook.Signer
+	if cfg.WebhookSubscriptionsFile != "" {
+		if eventStream == nil {
+			slog.Error("WEBHOOK_SUBSCRIPTIONS_FILE requires the event stream (EVENT_STREAM_MAX_LEN > 0)")
+
+			return errors.New("WEBHOOK_SUBSCRIPTIONS_FILE requires the event stream (EVENT_STREAM_MAX_LEN > 0)")
+		}
+
+		subs, err := webhook.LoadSubscriptions(cfg.WebhookSubscriptionsFile)
+		if err != nil {
+			slog.Error("failed to load webhook subscriptions", slog.String("error", err.Error()))
+
+			return err
+		}
+
+		slog.InfoContext(ctx, "starting webhook dispatcher",
+			slog.String("event", "webhook.start"),
+			slog.Int("subscriptions", len(subs)),
+		)
+
+		go webhook.NewDispatcher(eventStream, followUps, subs).Run(ctx)
+		webhookSigner = webhook.NewSigner(subs)
+	}
+
+	destinations, err := worker.NewDestinationPolicy(
+		cfg.DestinationAllowHosts,
+		cfg.DestinationDenyHosts,
+		cfg.DestinationAllowCIDRs,
+		cfg.DestinationDenyCIDRs,
+	)
+	if err != nil {
+		slog.Error("failed to parse destination policy", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	workflows := workflow.NewEngine(rdb, followUps, cfg.WorkflowRetention)
+	if cfg.WorkflowRecoveryInterval > 0 {
+		go workflows.RunRecovery(ctx, cfg.WorkflowRecoveryInterval)
+	}
+
+	server := worker.NewServer(cfg,
+		worker.WithTraceLinkMode(linkMode),
+		worker.WithPayloadKeyring(keyring),
+		worker.WithDestinationPolicy(destinations),
+		worker.WithPayloadStore(payloadStore),
+		worker.WithForwardCompressed(cfg.PayloadForwardCompressed),
+		worker.WithFollowUpClient(followUps),
+		worker.WithWorkflows(workflows),
+		worker.WithAttemptHistory(attempts.NewStore(rdb, cfg.AttemptHistoryLimit, cfg.AttemptHistoryTTL)),
+		worker.WithHistory(historySink),
+		worker.WithEvents(eventStream),
+		worker.WithLabelIndex(labelIndex),
+		worker.WithWebhookSigner(webhookSigner),
+	)
+
+	// The Prometheus exporter needs the admin listener for its scrape endpoint
+	metricsHandler := obs.MetricsHandler()
+	if cfg.WorkerAdminEnabled || metricsHandler != nil {
+		admin := worker.NewAdminServer(cfg.WorkerAdminPort, server, Version, metricsHandler)
+
+		slog.InfoContext(ctx, "starting worker admin server",
+			slog.String("event", "admin.start"),
+			slog.Int("port", cfg.WorkerAdminPort),
+		)
+
+		go func() {
+			if err := admin.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.ErrorContext(ctx, "admin server exited with error",
+					slog.String("event", "admin.exit.fail"),
+					slog.String("error", err.Error()),
+				)
+			}
+		}()
+
+		defer func() {
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer shutdownCancel()
+
+			if err := admin.Shutdown(shutdownCtx); err != nil {
+				slog.Warn("failed to shutdown admin server", slog.String("error", err.Error()))
+			}
+		}()
+	}
 
 	slog.InfoContext(ctx, "starting worker",
 		slog.String("event", "worker.start"),
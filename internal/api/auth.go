@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
+)
+
+// authenticate rejects requests without valid credentials and attaches the
+// authenticated principal to the request context.
+func authenticate(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authenticator.Authenticate(r)
+			if err != nil {
+				reason := "invalid_credentials"
+				message := "invalid credentials"
+				if errors.Is(err, auth.ErrNoCredentials) {
+					reason = "missing_credentials"
+					message = "authentication required"
+				}
+
+				slog.WarnContext(r.Context(), "authentication failed",
+					slog.String("event", "auth.fail"),
+					slog.String("reason", reason),
+					slog.String("error", err.Error()),
+				)
+
+				w.Header().Set("WWW-Authenticate", `Bearer realm="primind-tasks"`)
+				WriteError(w, http.StatusUnauthorized, StatusUnauthenticated, message)
+				return
+			}
+
+			ctx := auth.WithPrincipal(r.Context(), principal)
+			ctx = logging.WithPrincipal(ctx, principal.ID)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+
+	pjson "github.com/KasumiMercury/primind-tasks/internal/proto"
+)
+
+const (
+	contentTypeJSON     = "application/json"
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+// requestEncoding returns the canonical content type of the request body.
+// A missing Content-Type is treated as JSON for backward compatibility.
+func requestEncoding(r *http.Request) (string, bool) {
+	header := r.Header.Get("Content-Type")
+	if header == "" {
+		return contentTypeJSON, true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", false
+	}
+
+	switch mediaType {
+	case contentTypeJSON:
+		return contentTypeJSON, true
+	case contentTypeProtobuf, "application/protobuf", "application/proto":
+		return contentTypeProtobuf, true
+	default:
+		return "", false
+	}
+}
+
+// decodeRequest reads, decodes and validates the request body into msg,
+// writing the error response itself when it returns false.
+func (h *Handler) decodeRequest(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+	encoding, ok := requestEncoding(r)
+	if !ok {
+		WriteError(w, http.StatusUnsupportedMediaType, StatusInvalidArgument,
+			fmt.Sprintf("unsupported content type %q, use %s or %s", r.Header.Get("Content-Type"), contentTypeJSON, contentTypeProtobuf))
+		return false
+	}
+
+	if h.limits.MaxRequestBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.limits.MaxRequestBytes)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			WriteError(w, http.StatusRequestEntityTooLarge, StatusInvalidArgument,
+				fmt.Sprintf("request body exceeds the limit of %d bytes", maxBytesErr.Limit))
+			return false
+		}
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("failed to read request body: %v", err))
+		return false
+	}
+
+	if encoding == contentTypeProtobuf {
+		err = proto.Unmarshal(body, msg)
+	} else {
+		err = pjson.Unmarshal(body, msg)
+	}
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+
+	if err := pjson.Validate(msg); err != nil {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("validation error: %v", err))
+		return false
+	}
+
+	return true
+}
+
+// writeResponse encodes msg with the encoding of the request body, so binary
+// protobuf clients receive binary protobuf responses.
+func writeResponse(w http.ResponseWriter, r *http.Request, msg proto.Message) {
+	encoding, ok := requestEncoding(r)
+	if !ok || r.Method == http.MethodDelete || r.Method == http.MethodGet {
+		encoding = contentTypeJSON
+	}
+	if accept := r.Header.Get("Accept"); accept == contentTypeProtobuf {
+		encoding = contentTypeProtobuf
+	}
+
+	var (
+		respBytes []byte
+		err       error
+	)
+	if encoding == contentTypeProtobuf {
+		respBytes, err = proto.Marshal(msg)
+	} else {
+		respBytes, err = pjson.Marshal(msg)
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to marshal response")
+		return
+	}
+
+	w.Header().Set("Content-Type", encoding)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(respBytes); err != nil {
+		slog.Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
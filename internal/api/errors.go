@@ -17,10 +17,15 @@ type ErrorDetail struct {
 }
 
 const (
-	StatusAlreadyExists   = "ALREADY_EXISTS"
-	StatusInvalidArgument = "INVALID_ARGUMENT"
-	StatusInternal        = "INTERNAL"
-	StatusNotFound        = "NOT_FOUND"
+	StatusAborted            = "ABORTED"
+	StatusAlreadyExists      = "ALREADY_EXISTS"
+	StatusFailedPrecondition = "FAILED_PRECONDITION"
+	StatusInvalidArgument    = "INVALID_ARGUMENT"
+	StatusInternal           = "INTERNAL"
+	StatusNotFound           = "NOT_FOUND"
+	StatusPermissionDenied   = "PERMISSION_DENIED"
+	StatusUnauthenticated    = "UNAUTHENTICATED"
+	StatusUnavailable        = "UNAVAILABLE"
 )
 
 func WriteError(w http.ResponseWriter, code int, status string, message string) {
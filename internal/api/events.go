@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	pjson "github.com/KasumiMercury/primind-tasks/internal/proto"
+)
+
+const (
+	// eventReadCount bounds the events sent per read of the stream
+	eventReadCount = 100
+	// eventReadBlock bounds how long a read waits for new events; a
+	// keepalive comment is sent after an idle read
+	eventReadBlock = 5 * time.Second
+)
+
+// StreamEvents serves the lifecycle events of a queue as Server-Sent Events.
+// It resumes after the event named by the Last-Event-ID header (or the
+// lastEventId parameter), and otherwise sends only events published from now
+// on.
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	queueName := query.Get("queue")
+	if queueName == "" {
+		queueName = h.client.DefaultQueueName()
+	}
+
+	if !h.authorize(ctx, w, queueName, auth.VerbRead) {
+		return
+	}
+
+	if h.streamSlots != nil {
+		select {
+		case h.streamSlots <- struct{}{}:
+			defer func() { <-h.streamSlots }()
+		default:
+			WriteError(w, http.StatusServiceUnavailable, StatusUnavailable, "too many open event streams")
+			return
+		}
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = query.Get("lastEventId")
+	}
+	if lastID != "" && !events.ValidID(lastID) {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+			fmt.Sprintf("invalid Last-Event-ID %q", lastID))
+		return
+	}
+	if lastID == "" {
+		id, err := h.events.LastID(ctx, queueName)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to read event stream",
+				slog.String("event", "events.read.fail"),
+				slog.String("error", err.Error()),
+			)
+			WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to read events")
+			return
+		}
+		lastID = id
+	}
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.closing:
+			return
+		default:
+		}
+
+		evs, err := h.events.Read(ctx, queueName, lastID, eventReadCount, eventReadBlock)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to read event stream",
+					slog.String("event", "events.read.fail"),
+					slog.String("error", err.Error()),
+				)
+			}
+			return
+		}
+
+		if len(evs) == 0 {
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+		}
+		for _, ev := range evs {
+			data, merr := pjson.Marshal(ev.ToProto())
+			if merr != nil {
+				continue
+			}
+			if _, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", ev.ID, data); err != nil {
+				break
+			}
+			lastID = ev.ID
+		}
+		if err != nil {
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
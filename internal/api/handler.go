@@ -6,64 +6,100 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/hibiken/asynq"
-
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.38.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/KasumiMercury/primind-tasks/internal/attempts"
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
 	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+	"github.com/KasumiMercury/primind-tasks/internal/labels"
 	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
 	"github.com/KasumiMercury/primind-tasks/internal/observability/tracing"
-	pjson "github.com/KasumiMercury/primind-tasks/internal/proto"
 	"github.com/KasumiMercury/primind-tasks/internal/queue"
+	"github.com/KasumiMercury/primind-tasks/internal/schedule"
+	"github.com/KasumiMercury/primind-tasks/internal/workflow"
 )
 
 type Handler struct {
 	client *queue.Client
+	tracer trace.Tracer
+	limits RequestLimits
+	// schedules stores recurring tasks; nil disables the schedule endpoints
+	schedules *schedule.Store
+	// workflows runs DAGs of tasks; nil disables the workflow endpoints
+	workflows *workflow.Engine
+	// attempts holds the dispatch history of tasks; nil disables the
+	// attempts endpoint
+	attempts *attempts.Store
+	// history searches the task history database; nil disables the history
+	// endpoint
+	history *history.Store
+	// events streams task lifecycle events; nil disables the events endpoint
+	events *events.Stream
+	// streamSlots bounds the open event streams; nil leaves them unbounded
+	streamSlots chan struct{}
+	// closing is closed on shutdown to end open event streams
+	closing chan struct{}
+	// policy authorizes queue operations; nil allows everything
+	policy *auth.Policy
 }
 
-func NewHandler(client *queue.Client) *Handler {
-	return &Handler{client: client}
+// RequestLimits bounds the size of accepted requests. Zero disables a limit.
+type RequestLimits struct {
+	// MaxRequestBytes limits the encoded HTTP request body
+	MaxRequestBytes int64
+	// MaxTaskBodyBytes limits the decoded task body stored in Redis
+	MaxTaskBodyBytes int64
 }
 
-func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("failed to read request body: %v", err))
-		return
+func NewHandler(client *queue.Client, limits RequestLimits) *Handler {
+	return &Handler{
+		client:  client,
+		tracer:  otel.Tracer("github.com/KasumiMercury/primind-tasks/internal/api"),
+		limits:  limits,
+		closing: make(chan struct{}),
 	}
+}
 
-	var req taskqueuev1.CreateTaskRequest
-	if err := pjson.Unmarshal(body, &req); err != nil {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid request body: %v", err))
+func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	h.createTaskInQueue(w, r, h.client.DefaultQueueName())
+}
+
+func (h *Handler) CreateTaskWithQueue(w http.ResponseWriter, r *http.Request) {
+	queueName := chi.URLParam(r, "queue")
+	if queueName == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "queue name is required")
 		return
 	}
 
-	if err := pjson.Validate(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("validation error: %v", err))
+	h.createTaskInQueue(w, r, queueName)
+}
+
+func (h *Handler) createTaskInQueue(w http.ResponseWriter, r *http.Request, queueName string) {
+	if !h.authorize(r.Context(), w, queueName, auth.VerbEnqueue) {
 		return
 	}
 
-	decodedBody, err := base64.StdEncoding.DecodeString(req.Task.HttpRequest.Body)
-	if err != nil {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid base64 body: %v", err))
+	var req taskqueuev1.CreateTaskRequest
+	if !h.decodeRequest(w, r, &req) {
 		return
 	}
 
-	payload := queue.NewTaskPayload(decodedBody, req.Task.HttpRequest.Headers)
-
-	// Inject trace context (traceparent/tracestate) into task headers
-	tracing.InjectToMap(r.Context(), payload.Headers)
-
-	// Inject x-request-id into task headers
-	reqID := logging.RequestIDFromContext(r.Context())
-	if reqID == "" {
-		reqID = logging.ValidateAndExtractRequestID("")
+	payload, ok := h.buildTaskPayload(w, r, req.Task, queueName)
+	if !ok {
+		return
 	}
-	payload.Headers["x-request-id"] = reqID
 
 	var scheduleTime *time.Time
 	if req.Task.ScheduleTime != "" {
@@ -75,15 +111,27 @@ func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		scheduleTime = &t
 	}
 
-	info, err := h.client.EnqueueTask(payload, scheduleTime, req.Task.Name)
+	info, err := h.enqueueTask(r.Context(), payload, scheduleTime, queueName, req.Task.Name)
 	if err != nil {
 		if errors.Is(err, asynq.ErrTaskIDConflict) {
 			WriteError(w, http.StatusConflict, StatusAlreadyExists, fmt.Sprintf("task with name %q already exists", req.Task.Name))
 			return
 		}
+		var dup *queue.DuplicateTaskError
+		if errors.As(err, &dup) {
+			slog.InfoContext(r.Context(), "rejected duplicate task",
+				slog.String("event", "task.enqueue.duplicate"),
+				slog.String("queue", queueName),
+				slog.String("task_id", dup.TaskID),
+			)
+			WriteError(w, http.StatusConflict, StatusAlreadyExists,
+				fmt.Sprintf("identical task %q was enqueued within the deduplication window", "tasks/"+dup.TaskID))
+			return
+		}
 		slog.ErrorContext(r.Context(), "failed to enqueue task",
 			slog.String("event", "task.enqueue.fail"),
 			slog.String("error", err.Error()),
+			slog.String("queue", queueName),
 		)
 		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to enqueue task")
 		return
@@ -102,110 +150,143 @@ func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		resp.ScheduleTime = scheduleTime.Format(time.RFC3339)
 	}
 
-	respBytes, err := pjson.Marshal(resp)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to marshal response")
-		return
+	writeResponse(w, r, resp)
+}
+
+// buildTaskPayload converts a task from a request into a payload, encoding
+// its onSuccess/onFailure follow-ups for queueName, and writes the error
+// response itself when it returns false.
+func (h *Handler) buildTaskPayload(w http.ResponseWriter, r *http.Request, task *taskqueuev1.Task, queueName string) (*queue.TaskPayload, bool) {
+	body, ok := h.decodeTaskBody(w, task.HttpRequest.Body)
+	if !ok {
+		return nil, false
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(respBytes); err != nil {
-		slog.Warn("failed to write response", slog.String("error", err.Error()))
+	if target := task.HttpRequest.Url; target != "" {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid url %q: an absolute http(s) URL is required", target))
+			return nil, false
+		}
 	}
-}
 
-func (h *Handler) CreateTaskWithQueue(w http.ResponseWriter, r *http.Request) {
-	queueName := chi.URLParam(r, "queue")
-	if queueName == "" {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "queue name is required")
-		return
+	if err := labels.Validate(task.Labels); err != nil {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, err.Error())
+		return nil, false
+	}
+
+	payload := queue.NewTaskPayload(body, task.HttpRequest.Headers)
+	payload.URL = task.HttpRequest.Url
+	payload.Labels = task.Labels
+
+	for _, followUp := range []struct {
+		task *taskqueuev1.Task
+		dst  *[]byte
+	}{
+		{task.OnSuccess, &payload.OnSuccess},
+		{task.OnFailure, &payload.OnFailure},
+	} {
+		if followUp.task == nil {
+			continue
+		}
+
+		followUpPayload, ok := h.buildTaskPayload(w, r, followUp.task, queueName)
+		if !ok {
+			return nil, false
+		}
+
+		data, err := h.client.EncodePayload(followUpPayload, queueName)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to encode follow-up task",
+				slog.String("event", "task.enqueue.fail"),
+				slog.String("error", err.Error()),
+			)
+			WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to encode follow-up task")
+			return nil, false
+		}
+		*followUp.dst = data
 	}
 
-	body, err := io.ReadAll(r.Body)
+	return payload, true
+}
+
+// decodeTaskBody decodes the base64 task body and enforces MaxTaskBodyBytes,
+// writing the error response itself when it returns false.
+func (h *Handler) decodeTaskBody(w http.ResponseWriter, encoded string) ([]byte, bool) {
+	body, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("failed to read request body: %v", err))
-		return
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid base64 body: %v", err))
+		return nil, false
 	}
 
-	var req taskqueuev1.CreateTaskRequest
-	if err := pjson.Unmarshal(body, &req); err != nil {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid request body: %v", err))
-		return
+	if h.limits.MaxTaskBodyBytes > 0 && int64(len(body)) > h.limits.MaxTaskBodyBytes {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+			fmt.Sprintf("task body is %d bytes, exceeds the limit of %d bytes", len(body), h.limits.MaxTaskBodyBytes))
+		return nil, false
 	}
 
-	if err := pjson.Validate(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("validation error: %v", err))
-		return
+	return body, true
+}
+
+// authorize checks the caller against the queue policy and writes a
+// PERMISSION_DENIED error when the operation is not allowed.
+func (h *Handler) authorize(ctx context.Context, w http.ResponseWriter, queueName string, verb auth.Verb) bool {
+	if h.policy == nil {
+		return true
 	}
 
-	decodedBody, err := base64.StdEncoding.DecodeString(req.Task.HttpRequest.Body)
-	if err != nil {
-		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid base64 body: %v", err))
-		return
+	principal := auth.PrincipalFromContext(ctx)
+	if h.policy.Allowed(principal, queueName, verb) {
+		return true
+	}
+
+	principalID := ""
+	if principal != nil {
+		principalID = principal.ID
 	}
 
-	payload := queue.NewTaskPayload(decodedBody, req.Task.HttpRequest.Headers)
+	slog.WarnContext(ctx, "permission denied",
+		slog.String("event", "auth.deny"),
+		slog.String("queue", queueName),
+		slog.String("verb", string(verb)),
+	)
+	WriteError(w, http.StatusForbidden, StatusPermissionDenied,
+		fmt.Sprintf("principal %q is not allowed to %s in queue %q", principalID, verb, queueName))
+
+	return false
+}
+
+// enqueueTask enqueues payload inside a task.enqueue producer span, whose
+// context is propagated to the worker through the task headers.
+func (h *Handler) enqueueTask(ctx context.Context, payload *queue.TaskPayload, scheduleTime *time.Time, queueName, taskName string) (*asynq.TaskInfo, error) {
+	ctx, span := h.tracer.Start(ctx, "task.enqueue",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(tracing.EnqueueSpanAttributes(queueName)...),
+		trace.WithAttributes(semconv.MessagingMessageBodySize(len(payload.Body))),
+	)
+	defer span.End()
 
 	// Inject trace context (traceparent/tracestate) into task headers
-	tracing.InjectToMap(r.Context(), payload.Headers)
+	tracing.InjectToMap(ctx, payload.Headers)
 
 	// Inject x-request-id into task headers
-	reqID := logging.RequestIDFromContext(r.Context())
+	reqID := logging.RequestIDFromContext(ctx)
 	if reqID == "" {
 		reqID = logging.ValidateAndExtractRequestID("")
 	}
 	payload.Headers["x-request-id"] = reqID
 
-	var scheduleTime *time.Time
-	if req.Task.ScheduleTime != "" {
-		t, err := time.Parse(time.RFC3339, req.Task.ScheduleTime)
-		if err != nil {
-			WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid scheduleTime format: %v", err))
-			return
-		}
-		scheduleTime = &t
-	}
-
-	info, err := h.client.EnqueueTaskWithQueue(payload, scheduleTime, queueName, req.Task.Name)
+	info, err := h.client.EnqueueTaskWithQueue(payload, scheduleTime, queueName, taskName)
 	if err != nil {
-		if errors.Is(err, asynq.ErrTaskIDConflict) {
-			WriteError(w, http.StatusConflict, StatusAlreadyExists, fmt.Sprintf("task with name %q already exists", req.Task.Name))
-			return
-		}
-		slog.ErrorContext(r.Context(), "failed to enqueue task",
-			slog.String("event", "task.enqueue.fail"),
-			slog.String("error", err.Error()),
-			slog.String("queue", queueName),
-		)
-		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to enqueue task")
-		return
-	}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "enqueue failed")
 
-	taskName := req.Task.Name
-	if taskName == "" {
-		taskName = fmt.Sprintf("tasks/%s", info.ID)
+		return nil, err
 	}
 
-	resp := &taskqueuev1.CreateTaskResponse{
-		Name:       taskName,
-		CreateTime: time.Now().Format(time.RFC3339),
-	}
-	if scheduleTime != nil {
-		resp.ScheduleTime = scheduleTime.Format(time.RFC3339)
-	}
+	span.SetAttributes(semconv.MessagingMessageID(info.ID))
 
-	respBytes, err := pjson.Marshal(resp)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to marshal response")
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(respBytes); err != nil {
-		slog.Warn("failed to write response", slog.String("error", err.Error()))
-	}
+	return info, nil
 }
 
 // HealthCheck returns a simple health check response for backward compatibility.
@@ -270,7 +351,7 @@ func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.deleteTaskFromQueue(r.Context(), w, h.client.DefaultQueueName(), taskID)
+	h.deleteTaskFromQueue(w, r, h.client.DefaultQueueName(), taskID)
 }
 
 func (h *Handler) DeleteTaskWithQueue(w http.ResponseWriter, r *http.Request) {
@@ -286,10 +367,16 @@ func (h *Handler) DeleteTaskWithQueue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.deleteTaskFromQueue(r.Context(), w, queueName, taskID)
+	h.deleteTaskFromQueue(w, r, queueName, taskID)
 }
 
-func (h *Handler) deleteTaskFromQueue(ctx context.Context, w http.ResponseWriter, queueName, taskID string) {
+func (h *Handler) deleteTaskFromQueue(w http.ResponseWriter, r *http.Request, queueName, taskID string) {
+	ctx := r.Context()
+
+	if !h.authorize(ctx, w, queueName, auth.VerbDelete) {
+		return
+	}
+
 	err := h.client.DeleteTaskFromQueue(queueName, taskID)
 	if err != nil {
 		if errors.Is(err, asynq.ErrQueueNotFound) || errors.Is(err, asynq.ErrTaskNotFound) {
@@ -308,16 +395,5 @@ func (h *Handler) deleteTaskFromQueue(ctx context.Context, w http.ResponseWriter
 		return
 	}
 
-	resp := &taskqueuev1.DeleteTaskResponse{}
-	respBytes, err := pjson.Marshal(resp)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to marshal response")
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(respBytes); err != nil {
-		slog.Warn("failed to write response", slog.String("error", err.Error()))
-	}
+	writeResponse(w, r, &taskqueuev1.DeleteTaskResponse{})
 }
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+)
+
+const (
+	defaultHistoryPageSize = 100
+	maxHistoryPageSize     = 1000
+)
+
+var historyEventTypes = []string{
+	history.EventCreated,
+	history.EventDispatched,
+	history.EventSucceeded,
+	history.EventFailed,
+	history.EventDeleted,
+}
+
+// ListHistory searches task lifecycle events by task name, queue, event type
+// and time range, newest first. Events of queues the caller may not read are
+// left out.
+func (h *Handler) ListHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	filter := history.Filter{
+		TaskID: query.Get("name"),
+		Queue:  query.Get("queue"),
+		Type:   strings.ToUpper(query.Get("type")),
+		Limit:  defaultHistoryPageSize,
+	}
+
+	if filter.Type != "" && !slices.Contains(historyEventTypes, filter.Type) {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+			fmt.Sprintf("invalid type %q: use one of %s", filter.Type, strings.Join(historyEventTypes, ", ")))
+		return
+	}
+
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+				fmt.Sprintf("invalid %s %q: use RFC3339", param.name, value))
+			return
+		}
+		*param.target = t
+	}
+
+	if value := query.Get("pageSize"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxHistoryPageSize {
+			WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+				fmt.Sprintf("invalid pageSize %q: use 1 to %d", value, maxHistoryPageSize))
+			return
+		}
+		filter.Limit = size
+	}
+
+	if value := query.Get("pageToken"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 1 {
+			WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "invalid pageToken")
+			return
+		}
+		filter.BeforeID = id
+	}
+
+	if filter.Queue != "" && !h.authorize(ctx, w, filter.Queue, auth.VerbRead) {
+		return
+	}
+
+	events, err := h.history.Search(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to search history",
+			slog.String("event", "history.search.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to search history")
+		return
+	}
+
+	principal := auth.PrincipalFromContext(ctx)
+	resp := &taskqueuev1.ListHistoryResponse{
+		Events: make([]*taskqueuev1.HistoryEvent, 0, len(events)),
+	}
+	for _, ev := range events {
+		if h.policy != nil && !h.policy.Allowed(principal, ev.Queue, auth.VerbRead) {
+			continue
+		}
+		resp.Events = append(resp.Events, historyEventToProto(ev))
+	}
+
+	// A full page may have more events below it, even when some of them
+	// were filtered out above
+	if len(events) == filter.Limit {
+		resp.NextPageToken = strconv.FormatInt(events[len(events)-1].ID, 10)
+	}
+
+	writeResponse(w, r, resp)
+}
+
+func historyEventToProto(ev history.Event) *taskqueuev1.HistoryEvent {
+	return &taskqueuev1.HistoryEvent{
+		Id:             ev.ID,
+		Name:           ev.TaskID,
+		Queue:          ev.Queue,
+		Type:           ev.Type,
+		Time:           ev.OccurredAt.Format(time.RFC3339),
+		ResponseStatus: int32(ev.ResponseStatus),
+		Reason:         ev.Reason,
+		Error:          ev.Error,
+		AttemptNumber:  int32(ev.AttemptNumber),
+		Worker:         ev.Worker,
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hibiken/asynq"
+
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+	"github.com/KasumiMercury/primind-tasks/internal/labels"
+)
+
+// ListTasksWithQueue returns the tasks of a queue having all labels given
+// as label=key:value parameters. Completed tasks are not listed.
+func (h *Handler) ListTasksWithQueue(w http.ResponseWriter, r *http.Request) {
+	queueName := chi.URLParam(r, "queue")
+	if queueName == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "queue name is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	if !h.authorize(ctx, w, queueName, auth.VerbRead) {
+		return
+	}
+
+	params := r.URL.Query()["label"]
+	if len(params) == 0 {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "at least one label=key:value parameter is required")
+		return
+	}
+
+	selector, err := labels.ParseSelector(params)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, err.Error())
+		return
+	}
+
+	tasks, ok := h.findTasks(w, r, queueName, selector)
+	if !ok {
+		return
+	}
+
+	resp := &taskqueuev1.ListTasksResponse{Tasks: make([]*taskqueuev1.Task, 0, len(tasks))}
+	for _, info := range tasks {
+		resp.Tasks = append(resp.Tasks, taskInfoToProto(info))
+	}
+
+	writeResponse(w, r, resp)
+}
+
+// BatchDeleteTasks deletes the tasks of a queue having all labels of the
+// request. Active tasks are cancelled like with a single delete.
+func (h *Handler) BatchDeleteTasks(w http.ResponseWriter, r *http.Request) {
+	h.batchTasks(w, r, auth.VerbDelete, "delete", func(info *asynq.TaskInfo) (bool, error) {
+		return true, h.client.DeleteTaskFromQueue(info.Queue, info.ID)
+	})
+}
+
+// BatchRunTasks runs the scheduled, retrying and archived tasks of a queue
+// having all labels of the request right away. Pending and active tasks are
+// left as they are.
+func (h *Handler) BatchRunTasks(w http.ResponseWriter, r *http.Request) {
+	h.batchTasks(w, r, auth.VerbEnqueue, "run", func(info *asynq.TaskInfo) (bool, error) {
+		switch info.State {
+		case asynq.TaskStateScheduled, asynq.TaskStateRetry, asynq.TaskStateArchived:
+			return true, h.client.RunTask(info.Queue, info.ID)
+		default:
+			return false, nil
+		}
+	})
+}
+
+// batchTasks applies op to the tasks selected by a BatchTasksRequest and
+// responds with the names of the tasks op was applied to. op reports
+// whether it applied to the task.
+func (h *Handler) batchTasks(w http.ResponseWriter, r *http.Request, verb auth.Verb, opName string, op func(*asynq.TaskInfo) (bool, error)) {
+	queueName := chi.URLParam(r, "queue")
+	if queueName == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "queue name is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	if !h.authorize(ctx, w, queueName, verb) {
+		return
+	}
+
+	var req taskqueuev1.BatchTasksRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := labels.Validate(req.Labels); err != nil {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, err.Error())
+		return
+	}
+
+	tasks, ok := h.findTasks(w, r, queueName, req.Labels)
+	if !ok {
+		return
+	}
+
+	resp := &taskqueuev1.BatchTasksResponse{Names: []string{}}
+	failed := 0
+	for _, info := range tasks {
+		applied, err := op(info)
+		if errors.Is(err, asynq.ErrTaskNotFound) {
+			// Finished or deleted in the meantime
+			continue
+		}
+		if err != nil {
+			failed++
+			slog.ErrorContext(ctx, "failed to apply batch operation to task",
+				slog.String("event", "task.batch_"+opName+".fail"),
+				slog.String("error", err.Error()),
+				slog.String("queue", queueName),
+				slog.String("task_id", info.ID),
+			)
+			continue
+		}
+		if applied {
+			resp.Names = append(resp.Names, fmt.Sprintf("tasks/%s", info.ID))
+		}
+	}
+
+	slog.InfoContext(ctx, "batch operation finished",
+		slog.String("event", "task.batch_"+opName),
+		slog.String("queue", queueName),
+		slog.Int("task.count", len(resp.Names)),
+		slog.Int("task.failed", failed),
+	)
+
+	// The operations are idempotent, so the whole request can be retried
+	if failed > 0 {
+		WriteError(w, http.StatusInternalServerError, StatusInternal,
+			fmt.Sprintf("failed to %s %d of %d tasks", opName, failed, len(tasks)))
+		return
+	}
+
+	writeResponse(w, r, resp)
+}
+
+// findTasks looks up the tasks matching selector, writing the error
+// response itself when it returns false.
+func (h *Handler) findTasks(w http.ResponseWriter, r *http.Request, queueName string, selector map[string]string) ([]*asynq.TaskInfo, bool) {
+	tasks, err := h.client.FindTasks(r.Context(), queueName, selector)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to find tasks by label",
+			slog.String("event", "task.find.fail"),
+			slog.String("error", err.Error()),
+			slog.String("queue", queueName),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to find tasks")
+		return nil, false
+	}
+
+	return tasks, true
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+	"github.com/KasumiMercury/primind-tasks/internal/schedule"
+)
+
+// resourceIDPattern restricts caller-chosen schedule, workflow and node IDs.
+var resourceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,100}$`)
+
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req taskqueuev1.CreateScheduleRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+	spec := req.Schedule
+
+	queueName := spec.Queue
+	if queueName == "" {
+		queueName = h.client.DefaultQueueName()
+	}
+
+	if !h.authorize(r.Context(), w, queueName, auth.VerbEnqueue) {
+		return
+	}
+
+	id := spec.Name
+	if id == "" {
+		id = uuid.NewString()
+	} else if !resourceIDPattern.MatchString(id) {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+			fmt.Sprintf("invalid schedule name %q: use up to 100 letters, digits, '-' or '_'", id))
+		return
+	}
+
+	timeZone := spec.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	if err := schedule.ValidateCron(spec.Schedule, timeZone); err != nil {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, err.Error())
+		return
+	}
+
+	payload, ok := h.buildTaskPayload(w, r, spec.Task, queueName)
+	if !ok {
+		return
+	}
+
+	data, err := h.client.EncodePayload(payload, queueName)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to encode schedule payload",
+			slog.String("event", "schedule.create.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to create schedule")
+		return
+	}
+
+	now := time.Now()
+	sched := &schedule.Schedule{
+		ID:        id,
+		Cron:      spec.Schedule,
+		TimeZone:  timeZone,
+		Queue:     queueName,
+		Payload:   data,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.schedules.Create(r.Context(), sched); err != nil {
+		if errors.Is(err, schedule.ErrAlreadyExists) {
+			WriteError(w, http.StatusConflict, StatusAlreadyExists, fmt.Sprintf("schedule with name %q already exists", id))
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to create schedule",
+			slog.String("event", "schedule.create.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to create schedule")
+		return
+	}
+
+	writeResponse(w, r, scheduleToProto(sched))
+}
+
+// ListSchedules returns the schedules of every queue the caller may read.
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.schedules.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list schedules",
+			slog.String("event", "schedule.list.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to list schedules")
+		return
+	}
+
+	principal := auth.PrincipalFromContext(r.Context())
+	resp := &taskqueuev1.ListSchedulesResponse{
+		Schedules: make([]*taskqueuev1.Schedule, 0, len(schedules)),
+	}
+	for _, sched := range schedules {
+		if h.policy != nil && !h.policy.Allowed(principal, sched.Queue, auth.VerbRead) {
+			continue
+		}
+		resp.Schedules = append(resp.Schedules, scheduleToProto(sched))
+	}
+
+	writeResponse(w, r, resp)
+}
+
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	sched, ok := h.lookupSchedule(w, r, auth.VerbRead)
+	if !ok {
+		return
+	}
+
+	writeResponse(w, r, scheduleToProto(sched))
+}
+
+func (h *Handler) PauseSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setSchedulePaused(w, r, true)
+}
+
+func (h *Handler) ResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setSchedulePaused(w, r, false)
+}
+
+func (h *Handler) setSchedulePaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	sched, ok := h.lookupSchedule(w, r, auth.VerbEnqueue)
+	if !ok {
+		return
+	}
+
+	sched, err := h.schedules.SetPaused(r.Context(), sched.ID, paused)
+	if err != nil {
+		if errors.Is(err, schedule.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, StatusNotFound, fmt.Sprintf("schedule %q not found", chi.URLParam(r, "scheduleId")))
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to update schedule",
+			slog.String("event", "schedule.update.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to update schedule")
+		return
+	}
+
+	writeResponse(w, r, scheduleToProto(sched))
+}
+
+func (h *Handler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	sched, ok := h.lookupSchedule(w, r, auth.VerbDelete)
+	if !ok {
+		return
+	}
+
+	if err := h.schedules.Delete(r.Context(), sched.ID); err != nil && !errors.Is(err, schedule.ErrNotFound) {
+		slog.ErrorContext(r.Context(), "failed to delete schedule",
+			slog.String("event", "schedule.delete.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to delete schedule")
+		return
+	}
+
+	writeResponse(w, r, &taskqueuev1.DeleteScheduleResponse{})
+}
+
+// lookupSchedule loads the schedule named in the URL and authorizes verb on
+// its queue, writing the error response itself when it returns false.
+func (h *Handler) lookupSchedule(w http.ResponseWriter, r *http.Request, verb auth.Verb) (*schedule.Schedule, bool) {
+	id := chi.URLParam(r, "scheduleId")
+	if id == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "schedule ID is required")
+		return nil, false
+	}
+
+	sched, err := h.schedules.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, schedule.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, StatusNotFound, fmt.Sprintf("schedule %q not found", id))
+			return nil, false
+		}
+		slog.ErrorContext(r.Context(), "failed to get schedule",
+			slog.String("event", "schedule.get.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to get schedule")
+		return nil, false
+	}
+
+	if !h.authorize(r.Context(), w, sched.Queue, verb) {
+		return nil, false
+	}
+
+	return sched, true
+}
+
+func scheduleToProto(s *schedule.Schedule) *taskqueuev1.Schedule {
+	return &taskqueuev1.Schedule{
+		Name:       s.ID,
+		Schedule:   s.Cron,
+		TimeZone:   s.TimeZone,
+		Queue:      s.Queue,
+		State:      s.State(),
+		CreateTime: s.CreatedAt.Format(time.RFC3339),
+		UpdateTime: s.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -5,34 +5,126 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"connectrpc.com/grpchealth"
+	"github.com/go-chi/chi/v5"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
-	"github.com/go-chi/chi/v5"
 
+	"github.com/KasumiMercury/primind-tasks/internal/attempts"
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
 	"github.com/KasumiMercury/primind-tasks/internal/config"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
 	"github.com/KasumiMercury/primind-tasks/internal/health"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
 	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
+	"github.com/KasumiMercury/primind-tasks/internal/observability/metrics"
 	obsmw "github.com/KasumiMercury/primind-tasks/internal/observability/middleware"
 	"github.com/KasumiMercury/primind-tasks/internal/queue"
+	"github.com/KasumiMercury/primind-tasks/internal/schedule"
+	"github.com/KasumiMercury/primind-tasks/internal/workflow"
 )
 
 type Server struct {
-	handler       *Handler
-	healthChecker *health.Checker
-	port          int
-	version       string
-	httpServer    *http.Server
+	handler        *Handler
+	healthChecker  *health.Checker
+	metricsHandler http.Handler
+	httpMetrics    *metrics.HTTPMetrics
+	authenticator  auth.Authenticator
+	port           int
+	version        string
+	httpServer     *http.Server
+	closeOnce      sync.Once
+}
+
+// ServerOption configures optional dependencies of the Server.
+type ServerOption func(*Server)
+
+// WithMetricsHandler serves h on /metrics (Prometheus scrape endpoint).
+func WithMetricsHandler(h http.Handler) ServerOption {
+	return func(s *Server) {
+		s.metricsHandler = h
+	}
+}
+
+// WithHTTPMetrics records request metrics through m.
+func WithHTTPMetrics(m *metrics.HTTPMetrics) ServerOption {
+	return func(s *Server) {
+		s.httpMetrics = m
+	}
+}
+
+// WithAuthenticator requires task endpoints to be called with credentials
+// accepted by a.
+func WithAuthenticator(a auth.Authenticator) ServerOption {
+	return func(s *Server) {
+		s.authenticator = a
+	}
+}
+
+// WithPolicy restricts queue operations to those granted by p.
+func WithPolicy(p *auth.Policy) ServerOption {
+	return func(s *Server) {
+		s.handler.policy = p
+	}
+}
+
+// WithSchedules enables the recurring task endpoints backed by store.
+func WithSchedules(store *schedule.Store) ServerOption {
+	return func(s *Server) {
+		s.handler.schedules = store
+	}
+}
+
+// WithWorkflows enables the workflow endpoints backed by engine.
+func WithWorkflows(engine *workflow.Engine) ServerOption {
+	return func(s *Server) {
+		s.handler.workflows = engine
+	}
+}
+
+// WithAttemptHistory enables the attempt history endpoint backed by store.
+func WithAttemptHistory(store *attempts.Store) ServerOption {
+	return func(s *Server) {
+		s.handler.attempts = store
+	}
 }
 
-func NewServer(cfg *config.Config, client *queue.Client, version string) *Server {
-	return &Server{
-		handler:       NewHandler(client),
+// WithHistory enables the task history endpoint backed by store.
+func WithHistory(store *history.Store) ServerOption {
+	return func(s *Server) {
+		s.handler.history = store
+	}
+}
+
+// WithEvents enables the event stream endpoint backed by stream, serving at
+// most maxStreams streams at once (unbounded when 0).
+func WithEvents(stream *events.Stream, maxStreams int) ServerOption {
+	return func(s *Server) {
+		s.handler.events = stream
+		if maxStreams > 0 {
+			s.handler.streamSlots = make(chan struct{}, maxStreams)
+		}
+	}
+}
+
+func NewServer(cfg *config.Config, client *queue.Client, version string, opts ...ServerOption) *Server {
+	s := &Server{
+		handler: NewHandler(client, RequestLimits{
+			MaxRequestBytes:  cfg.MaxRequestBytes,
+			MaxTaskBodyBytes: cfg.MaxTaskBodyBytes,
+		}),
 		healthChecker: health.NewChecker(client, version),
 		port:          cfg.APIPort,
 		version:       version,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *Server) Router() http.Handler {
@@ -43,49 +135,125 @@ func (s *Server) Router() http.Handler {
 	r.Get("/health/ready", s.healthChecker.ReadyHandler)
 	r.Get("/health", s.healthChecker.ReadyHandler)
 
-	// Task creation
-	r.Post("/tasks", s.handler.CreateTask)
-	r.Post("/tasks/{queue}", s.handler.CreateTaskWithQueue)
+	// Prometheus scrape endpoint
+	if s.metricsHandler != nil {
+		r.Handle("/metrics", s.metricsHandler)
+	}
+
+	r.Group(func(r chi.Router) {
+		if s.authenticator != nil {
+			r.Use(authenticate(s.authenticator))
+		}
+
+		// Task creation
+		r.Post("/tasks", s.handler.CreateTask)
+		r.Post("/tasks/{queue}", s.handler.CreateTaskWithQueue)
 
-	// Task deletion
-	r.Delete("/tasks/{taskId}", s.handler.DeleteTask)
-	r.Delete("/tasks/{queue}/{taskId}", s.handler.DeleteTaskWithQueue)
+		// Task lookup
+		r.Get("/tasks/{queue}", s.handler.ListTasksWithQueue)
+		r.Get("/tasks/{queue}/{taskId}", s.handler.GetTaskWithQueue)
+		if s.handler.attempts != nil {
+			r.Get("/tasks/{queue}/{taskId}/attempts", s.handler.ListTaskAttempts)
+		}
+
+		// Task update
+		r.Patch("/tasks/{queue}/{taskId}", s.handler.UpdateTaskWithQueue)
+
+		// Task deletion
+		r.Delete("/tasks/{taskId}", s.handler.DeleteTask)
+		r.Delete("/tasks/{queue}/{taskId}", s.handler.DeleteTaskWithQueue)
+
+		// Batch operations by label
+		r.Post("/tasks/{queue}:batchDelete", s.handler.BatchDeleteTasks)
+		r.Post("/tasks/{queue}:batchRun", s.handler.BatchRunTasks)
+
+		// Task history
+		if s.handler.history != nil {
+			r.Get("/history", s.handler.ListHistory)
+		}
+
+		// Task lifecycle events
+		if s.handler.events != nil {
+			r.Get("/events", s.handler.StreamEvents)
+		}
+
+		// Recurring tasks
+		if s.handler.schedules != nil {
+			r.Post("/schedules", s.handler.CreateSchedule)
+			r.Get("/schedules", s.handler.ListSchedules)
+			r.Get("/schedules/{scheduleId}", s.handler.GetSchedule)
+			r.Post("/schedules/{scheduleId}:pause", s.handler.PauseSchedule)
+			r.Post("/schedules/{scheduleId}:resume", s.handler.ResumeSchedule)
+			r.Delete("/schedules/{scheduleId}", s.handler.DeleteSchedule)
+		}
+
+		// Workflows
+		if s.handler.workflows != nil {
+			r.Post("/workflows", s.handler.CreateWorkflow)
+			r.Get("/workflows/{workflowId}", s.handler.GetWorkflow)
+			r.Post("/workflows/{workflowId}:cancel", s.handler.CancelWorkflow)
+			r.Post("/workflows/{workflowId}:retry", s.handler.RetryWorkflow)
+		}
+	})
 
 	// gRPC Health Checking Protocol (grpc.health.v1.Health/Check)
 	grpcHealthChecker := health.NewGRPCChecker(s.healthChecker)
 	grpcHealthPath, grpcHealthHandler := grpchealth.NewHandler(grpcHealthChecker)
 
+	// Create multiplexed handler for chi + gRPC health
+	muxHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, grpcHealthPath) {
+			grpcHealthHandler.ServeHTTP(w, req)
+			return
+		}
+		r.ServeHTTP(w, req)
+	})
+
 	// Wrap with observability middleware
-	chiHandler := obsmw.HTTP(r, obsmw.HTTPConfig{
-		SkipPaths:  []string{"/health", "/health/live", "/health/ready"},
+	handler := obsmw.HTTP(muxHandler, obsmw.HTTPConfig{
+		SkipPaths:  []string{"/health", "/health/live", "/health/ready", "/metrics"},
 		Module:     logging.Module("taskqueue"),
 		TracerName: "github.com/KasumiMercury/primind-tasks/internal/observability/middleware",
 		SpanNameResolver: func(req *http.Request) string {
-			routeCtx := chi.RouteContext(req.Context())
-			if routeCtx == nil {
-				return ""
-			}
-
-			pattern := routeCtx.RoutePattern()
+			pattern := routePattern(req)
 			if pattern == "" {
 				return ""
 			}
 
 			return fmt.Sprintf("%s %s", req.Method, pattern)
 		},
+		Metrics: s.httpMetrics,
+		RouteResolver: func(req *http.Request) string {
+			if strings.HasPrefix(req.URL.Path, grpcHealthPath) {
+				return grpcHealthPath
+			}
+
+			return routePattern(req)
+		},
 	})
-	chiHandler = obsmw.PanicRecoveryHTTP(chiHandler)
+	handler = withRouteContext(handler)
+	handler = obsmw.PanicRecoveryHTTP(handler)
 
-	// Create multiplexed handler for chi + gRPC health
-	finalHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		if strings.HasPrefix(req.URL.Path, grpcHealthPath) {
-			grpcHealthHandler.ServeHTTP(w, req)
-			return
-		}
-		chiHandler.ServeHTTP(w, req)
+	return handler
+}
+
+// withRouteContext attaches an empty chi routing context before the
+// observability middleware, so the route pattern resolved by the router is
+// visible to it after the request is served.
+func withRouteContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, chi.NewRouteContext())
+		next.ServeHTTP(w, req.WithContext(ctx))
 	})
+}
 
-	return finalHandler
+func routePattern(req *http.Request) string {
+	routeCtx := chi.RouteContext(req.Context())
+	if routeCtx == nil {
+		return ""
+	}
+
+	return routeCtx.RoutePattern()
 }
 
 func (s *Server) ListenAndServe() error {
@@ -99,6 +267,12 @@ func (s *Server) ListenAndServe() error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	// Event streams never finish on their own, so they are ended here for
+	// the graceful shutdown to complete
+	s.closeOnce.Do(func() {
+		close(s.handler.closing)
+	})
+
 	if s.httpServer != nil {
 		return s.httpServer.Shutdown(ctx)
 	}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hibiken/asynq"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+// GetTaskWithQueue returns the state of a task and the outcome of its latest
+// dispatch attempt. The request body is not returned. Completed tasks are
+// only found while TASK_RESULT_RETENTION keeps them, so with the default of
+// 0 the latest attempt is only returned for tasks retrying or archived.
+func (h *Handler) GetTaskWithQueue(w http.ResponseWriter, r *http.Request) {
+	queueName := chi.URLParam(r, "queue")
+	if queueName == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "queue name is required")
+		return
+	}
+
+	taskID := chi.URLParam(r, "taskId")
+	if taskID == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "task ID is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	if !h.authorize(ctx, w, queueName, auth.VerbRead) {
+		return
+	}
+
+	info, err := h.client.GetTask(queueName, taskID)
+	if err != nil {
+		if errors.Is(err, asynq.ErrQueueNotFound) || errors.Is(err, asynq.ErrTaskNotFound) {
+			WriteError(w, http.StatusNotFound, StatusNotFound,
+				fmt.Sprintf("task %q not found in queue %q", taskID, queueName))
+			return
+		}
+
+		slog.ErrorContext(ctx, "failed to get task",
+			slog.String("event", "task.get.fail"),
+			slog.String("error", err.Error()),
+			slog.String("queue", queueName),
+			slog.String("task_id", taskID),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to get task")
+		return
+	}
+
+	writeResponse(w, r, taskInfoToProto(info))
+}
+
+// Fields of an UpdateTaskRequest named by its update mask.
+const (
+	updateScheduleTime = "scheduleTime"
+	updateHeaders      = "httpRequest.headers"
+	updateBody         = "httpRequest.body"
+)
+
+// UpdateTaskWithQueue changes the schedule time, headers or body of a
+// pending, scheduled or retrying task. With an etag, the update is only
+// applied if the task did not change since the etag was read.
+func (h *Handler) UpdateTaskWithQueue(w http.ResponseWriter, r *http.Request) {
+	queueName := chi.URLParam(r, "queue")
+	taskID := chi.URLParam(r, "taskId")
+	if queueName == "" || taskID == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "queue name and task ID are required")
+		return
+	}
+
+	ctx := r.Context()
+
+	if !h.authorize(ctx, w, queueName, auth.VerbEnqueue) {
+		return
+	}
+
+	var req taskqueuev1.UpdateTaskRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	update, ok := h.buildTaskUpdate(w, &req)
+	if !ok {
+		return
+	}
+
+	info, err := h.client.UpdateTask(ctx, queueName, taskID, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, asynq.ErrQueueNotFound) || errors.Is(err, asynq.ErrTaskNotFound):
+			WriteError(w, http.StatusNotFound, StatusNotFound,
+				fmt.Sprintf("task %q not found in queue %q", taskID, queueName))
+		case errors.Is(err, queue.ErrTaskNotUpdatable):
+			WriteError(w, http.StatusBadRequest, StatusFailedPrecondition, err.Error())
+		case errors.Is(err, queue.ErrTaskModified):
+			WriteError(w, http.StatusConflict, StatusAborted,
+				fmt.Sprintf("task %q was modified, read it again for the current etag", taskID))
+		default:
+			slog.ErrorContext(ctx, "failed to update task",
+				slog.String("event", "task.update.fail"),
+				slog.String("error", err.Error()),
+				slog.String("queue", queueName),
+				slog.String("task_id", taskID),
+			)
+			WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to update task")
+		}
+		return
+	}
+
+	slog.InfoContext(ctx, "task updated",
+		slog.String("event", "task.update"),
+		slog.String("queue", queueName),
+		slog.String("task_id", taskID),
+	)
+
+	writeResponse(w, r, taskInfoToProto(info))
+}
+
+// buildTaskUpdate converts an UpdateTaskRequest into the changes named by
+// its update mask, and writes the error response itself when it returns
+// false. Without a mask, the fields set in the request are changed.
+func (h *Handler) buildTaskUpdate(w http.ResponseWriter, req *taskqueuev1.UpdateTaskRequest) (queue.TaskUpdate, bool) {
+	update := queue.TaskUpdate{ETag: req.Etag}
+	httpRequest := req.GetHttpRequest()
+
+	if httpRequest.GetUrl() != "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "the url of a task cannot be changed")
+		return update, false
+	}
+
+	mask := map[string]bool{}
+	if req.UpdateMask == "" {
+		mask[updateScheduleTime] = req.ScheduleTime != ""
+		mask[updateHeaders] = len(httpRequest.GetHeaders()) > 0
+		mask[updateBody] = httpRequest.GetBody() != ""
+	}
+	for _, path := range strings.Split(req.UpdateMask, ",") {
+		switch strings.TrimSpace(path) {
+		case "":
+		case updateScheduleTime, "schedule_time":
+			mask[updateScheduleTime] = true
+		case updateHeaders, "http_request.headers":
+			mask[updateHeaders] = true
+		case updateBody, "http_request.body":
+			mask[updateBody] = true
+		default:
+			WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+				fmt.Sprintf("invalid updateMask field %q: use %s, %s or %s", path, updateScheduleTime, updateHeaders, updateBody))
+			return update, false
+		}
+	}
+
+	if !mask[updateScheduleTime] && !mask[updateHeaders] && !mask[updateBody] {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "no field to update is given")
+		return update, false
+	}
+
+	if mask[updateScheduleTime] {
+		t, err := time.Parse(time.RFC3339, req.ScheduleTime)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, StatusInvalidArgument, fmt.Sprintf("invalid scheduleTime format: %v", err))
+			return update, false
+		}
+		update.ScheduleTime = &t
+	}
+
+	if mask[updateHeaders] {
+		update.Headers = httpRequest.GetHeaders()
+		if update.Headers == nil {
+			update.Headers = map[string]string{}
+		}
+	}
+
+	if mask[updateBody] {
+		body, ok := h.decodeTaskBody(w, httpRequest.GetBody())
+		if !ok {
+			return update, false
+		}
+		update.Body = body
+		update.ReplaceBody = true
+	}
+
+	return update, true
+}
+
+// ListTaskAttempts returns the recorded dispatch attempts of a task, oldest
+// first. Tasks that exist but were not dispatched yet have no attempts.
+func (h *Handler) ListTaskAttempts(w http.ResponseWriter, r *http.Request) {
+	queueName := chi.URLParam(r, "queue")
+	taskID := chi.URLParam(r, "taskId")
+	if queueName == "" || taskID == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "queue name and task ID are required")
+		return
+	}
+
+	ctx := r.Context()
+
+	if !h.authorize(ctx, w, queueName, auth.VerbRead) {
+		return
+	}
+
+	attempts, err := h.attempts.List(ctx, queueName, taskID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list task attempts",
+			slog.String("event", "task.attempts.fail"),
+			slog.String("error", err.Error()),
+			slog.String("queue", queueName),
+			slog.String("task_id", taskID),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to list task attempts")
+		return
+	}
+
+	// The history outlives deleted tasks; without it, only existing tasks
+	// are answered
+	if len(attempts) == 0 {
+		if _, err := h.client.GetTask(queueName, taskID); err != nil {
+			if errors.Is(err, asynq.ErrQueueNotFound) || errors.Is(err, asynq.ErrTaskNotFound) {
+				WriteError(w, http.StatusNotFound, StatusNotFound,
+					fmt.Sprintf("task %q not found in queue %q", taskID, queueName))
+				return
+			}
+			slog.ErrorContext(ctx, "failed to get task",
+				slog.String("event", "task.get.fail"),
+				slog.String("error", err.Error()),
+				slog.String("queue", queueName),
+				slog.String("task_id", taskID),
+			)
+			WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to list task attempts")
+			return
+		}
+	}
+
+	writeResponse(w, r, &taskqueuev1.ListAttemptsResponse{Attempts: attempts})
+}
+
+func taskInfoToProto(info *asynq.TaskInfo) *taskqueuev1.Task {
+	task := &taskqueuev1.Task{
+		Name:       fmt.Sprintf("tasks/%s", info.ID),
+		State:      strings.ToUpper(info.State.String()),
+		RetryCount: int32(info.Retried),
+		Etag:       queue.TaskETag(info),
+	}
+	if !info.NextProcessAt.IsZero() {
+		task.ScheduleTime = info.NextProcessAt.Format(time.RFC3339)
+	}
+	if payload, err := queue.UnmarshalTaskPayload(info.Payload); err == nil {
+		if !payload.CreatedAt.IsZero() {
+			task.CreateTime = payload.CreatedAt.Format(time.RFC3339)
+		}
+		task.Labels = payload.Labels
+	}
+
+	// The worker writes the latest attempt as the task result
+	if len(info.Result) > 0 {
+		var attempt taskqueuev1.Attempt
+		if err := proto.Unmarshal(info.Result, &attempt); err == nil {
+			task.LastAttempt = &attempt
+		}
+	}
+
+	return task
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/KasumiMercury/primind-tasks/internal/auth"
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+	"github.com/KasumiMercury/primind-tasks/internal/workflow"
+)
+
+func (h *Handler) CreateWorkflow(w http.ResponseWriter, r *http.Request) {
+	var req taskqueuev1.CreateWorkflowRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+	spec := req.Workflow
+
+	queueName := spec.Queue
+	if queueName == "" {
+		queueName = h.client.DefaultQueueName()
+	}
+
+	if !h.authorize(r.Context(), w, queueName, auth.VerbEnqueue) {
+		return
+	}
+
+	id := spec.Name
+	if id == "" {
+		id = uuid.NewString()
+	} else if !resourceIDPattern.MatchString(id) {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+			fmt.Sprintf("invalid workflow name %q: use up to 100 letters, digits, '-' or '_'", id))
+		return
+	}
+
+	if len(spec.Nodes) > workflow.MaxNodes {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+			fmt.Sprintf("workflow has %d nodes, the limit is %d", len(spec.Nodes), workflow.MaxNodes))
+		return
+	}
+
+	now := time.Now()
+	wf := &workflow.Workflow{
+		ID:        id,
+		Queue:     queueName,
+		Nodes:     make([]*workflow.Node, 0, len(spec.Nodes)),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	for _, node := range spec.Nodes {
+		if !resourceIDPattern.MatchString(node.Id) {
+			WriteError(w, http.StatusBadRequest, StatusInvalidArgument,
+				fmt.Sprintf("invalid node id %q: use up to 100 letters, digits, '-' or '_'", node.Id))
+			return
+		}
+
+		payload, ok := h.buildTaskPayload(w, r, node.Task, queueName)
+		if !ok {
+			return
+		}
+
+		data, err := h.client.EncodePayload(payload, queueName)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to encode workflow node payload",
+				slog.String("event", "workflow.create.fail"),
+				slog.String("error", err.Error()),
+			)
+			WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to create workflow")
+			return
+		}
+
+		wf.Nodes = append(wf.Nodes, &workflow.Node{
+			ID:        node.Id,
+			DependsOn: node.DependsOn,
+			Payload:   data,
+		})
+	}
+
+	if err := wf.Validate(); err != nil {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, err.Error())
+		return
+	}
+
+	if err := h.workflows.Submit(r.Context(), wf); err != nil {
+		if errors.Is(err, workflow.ErrAlreadyExists) {
+			WriteError(w, http.StatusConflict, StatusAlreadyExists, fmt.Sprintf("workflow with name %q already exists", id))
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to create workflow",
+			slog.String("event", "workflow.create.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to create workflow")
+		return
+	}
+
+	writeResponse(w, r, workflowToProto(wf))
+}
+
+func (h *Handler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	wf, ok := h.lookupWorkflow(w, r, auth.VerbRead)
+	if !ok {
+		return
+	}
+
+	writeResponse(w, r, workflowToProto(wf))
+}
+
+func (h *Handler) CancelWorkflow(w http.ResponseWriter, r *http.Request) {
+	wf, ok := h.lookupWorkflow(w, r, auth.VerbDelete)
+	if !ok {
+		return
+	}
+
+	wf, err := h.workflows.Cancel(r.Context(), wf.ID)
+	if !h.checkWorkflowUpdate(w, r, err) {
+		return
+	}
+
+	writeResponse(w, r, workflowToProto(wf))
+}
+
+// RetryWorkflow reruns the failed and cancelled nodes of a workflow.
+func (h *Handler) RetryWorkflow(w http.ResponseWriter, r *http.Request) {
+	wf, ok := h.lookupWorkflow(w, r, auth.VerbEnqueue)
+	if !ok {
+		return
+	}
+
+	wf, err := h.workflows.Retry(r.Context(), wf.ID)
+	if !h.checkWorkflowUpdate(w, r, err) {
+		return
+	}
+
+	writeResponse(w, r, workflowToProto(wf))
+}
+
+// checkWorkflowUpdate writes the error response for a failed cancel or retry
+// and reports whether err was nil.
+func (h *Handler) checkWorkflowUpdate(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, workflow.ErrNotFound):
+		WriteError(w, http.StatusNotFound, StatusNotFound, fmt.Sprintf("workflow %q not found", chi.URLParam(r, "workflowId")))
+	case errors.Is(err, workflow.ErrInvalidState):
+		WriteError(w, http.StatusBadRequest, StatusFailedPrecondition, err.Error())
+	default:
+		slog.ErrorContext(r.Context(), "failed to update workflow",
+			slog.String("event", "workflow.update.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to update workflow")
+	}
+
+	return false
+}
+
+// lookupWorkflow loads the workflow named in the URL and authorizes verb on
+// its queue, writing the error response itself when it returns false.
+func (h *Handler) lookupWorkflow(w http.ResponseWriter, r *http.Request, verb auth.Verb) (*workflow.Workflow, bool) {
+	id := chi.URLParam(r, "workflowId")
+	if id == "" {
+		WriteError(w, http.StatusBadRequest, StatusInvalidArgument, "workflow ID is required")
+		return nil, false
+	}
+
+	wf, err := h.workflows.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, workflow.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, StatusNotFound, fmt.Sprintf("workflow %q not found", id))
+			return nil, false
+		}
+		slog.ErrorContext(r.Context(), "failed to get workflow",
+			slog.String("event", "workflow.get.fail"),
+			slog.String("error", err.Error()),
+		)
+		WriteError(w, http.StatusInternalServerError, StatusInternal, "failed to get workflow")
+		return nil, false
+	}
+
+	if !h.authorize(r.Context(), w, wf.Queue, verb) {
+		return nil, false
+	}
+
+	return wf, true
+}
+
+func workflowToProto(wf *workflow.Workflow) *taskqueuev1.Workflow {
+	msg := &taskqueuev1.Workflow{
+		Name:       wf.ID,
+		Queue:      wf.Queue,
+		Nodes:      make([]*taskqueuev1.WorkflowNode, 0, len(wf.Nodes)),
+		State:      wf.State,
+		CreateTime: wf.CreatedAt.Format(time.RFC3339),
+		UpdateTime: wf.UpdatedAt.Format(time.RFC3339),
+	}
+	for _, n := range wf.Nodes {
+		msg.Nodes = append(msg.Nodes, &taskqueuev1.WorkflowNode{
+			Id:        n.ID,
+			DependsOn: n.DependsOn,
+			State:     n.State,
+			TaskId:    n.TaskID,
+			Error:     n.Error,
+		})
+	}
+
+	return msg
+}
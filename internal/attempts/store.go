@@ -0,0 +1,77 @@
+// Package attempts keeps a bounded history of the dispatch attempts of each
+// task in Redis.
+package attempts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+)
+
+// keyPrefix prefixes the Redis list holding the attempts of a task.
+const keyPrefix = "primind:attempts:"
+
+// Store appends attempts to a per-task list, keeping the latest limit
+// entries for ttl after the last attempt.
+type Store struct {
+	rdb   redis.UniversalClient
+	limit int
+	ttl   time.Duration
+}
+
+func NewStore(rdb redis.UniversalClient, limit int, ttl time.Duration) *Store {
+	return &Store{
+		rdb:   rdb,
+		limit: limit,
+		ttl:   ttl,
+	}
+}
+
+func attemptsKey(queueName, taskID string) string {
+	return fmt.Sprintf("%s{%s}:%s", keyPrefix, queueName, taskID)
+}
+
+func (s *Store) Append(ctx context.Context, queueName, taskID string, attempt *taskqueuev1.Attempt) error {
+	data, err := proto.Marshal(attempt)
+	if err != nil {
+		return err
+	}
+
+	key := attemptsKey(queueName, taskID)
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, data)
+		if s.limit > 0 {
+			pipe.LTrim(ctx, key, int64(-s.limit), -1)
+		}
+		if s.ttl > 0 {
+			pipe.Expire(ctx, key, s.ttl)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// List returns the recorded attempts of a task, oldest first.
+func (s *Store) List(ctx context.Context, queueName, taskID string) ([]*taskqueuev1.Attempt, error) {
+	entries, err := s.rdb.LRange(ctx, attemptsKey(queueName, taskID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := make([]*taskqueuev1.Attempt, 0, len(entries))
+	for _, data := range entries {
+		var attempt taskqueuev1.Attempt
+		if err := proto.Unmarshal([]byte(data), &attempt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+
+	hashPrefix = "sha256:"
+)
+
+// APIKeyEntry is a single entry of the API key file.
+type APIKeyEntry struct {
+	Principal string   `json:"principal"`
+	KeyHash   string   `json:"key_hash"` // "sha256:<hex>"
+	Groups    []string `json:"groups,omitempty"`
+}
+
+type apiKey struct {
+	hash      []byte
+	principal Principal
+}
+
+// APIKeyAuthenticator checks the X-API-Key header against SHA-256 hashes of
+// the configured keys, so plain keys are never stored.
+type APIKeyAuthenticator struct {
+	keys []apiKey
+}
+
+// LoadAPIKeys reads a JSON array of APIKeyEntry from path.
+func LoadAPIKeys(path string) (*APIKeyAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []APIKeyEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse api key file: %w", err)
+	}
+
+	return NewAPIKeyAuthenticator(entries)
+}
+
+func NewAPIKeyAuthenticator(entries []APIKeyEntry) (*APIKeyAuthenticator, error) {
+	keys := make([]apiKey, 0, len(entries))
+	for i, e := range entries {
+		if e.Principal == "" {
+			return nil, fmt.Errorf("api key entry %d: principal is required", i)
+		}
+
+		encoded, ok := strings.CutPrefix(e.KeyHash, hashPrefix)
+		if !ok {
+			return nil, fmt.Errorf("api key %q: key_hash must start with %q", e.Principal, hashPrefix)
+		}
+
+		hash, err := hex.DecodeString(encoded)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("api key %q: invalid sha256 hash", e.Principal)
+		}
+
+		keys = append(keys, apiKey{
+			hash: hash,
+			principal: Principal{
+				ID:     e.Principal,
+				Groups: e.Groups,
+				Method: "api_key",
+			},
+		})
+	}
+
+	return &APIKeyAuthenticator{keys: keys}, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.hash) == 1 {
+			p := k.principal
+
+			return &p, nil
+		}
+	}
+
+	return nil, ErrInvalidCredentials
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+)
+
+// Verb is an operation subject to queue authorization.
+type Verb string
+
+const (
+	VerbEnqueue Verb = "enqueue"
+	VerbDelete  Verb = "delete"
+	VerbRead    Verb = "read"
+	VerbAdmin   Verb = "admin"
+
+	wildcard = "*"
+)
+
+// PolicyRule grants verbs on queues matching any of Queues to the listed
+// principals and members of the listed groups. Queue patterns use
+// path.Match syntax; "*" in Principals, Groups or Verbs matches anything.
+type PolicyRule struct {
+	Principals []string `json:"principals,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+	Queues     []string `json:"queues"`
+	Verbs      []Verb   `json:"verbs"`
+}
+
+// Policy authorizes queue operations. Anything not granted by a rule is denied.
+type Policy struct {
+	rules []PolicyRule
+}
+
+// LoadPolicy reads a JSON array of PolicyRule from path.
+func LoadPolicy(filePath string) (*Policy, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []PolicyRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse policy file: %w", err)
+	}
+
+	return NewPolicy(rules)
+}
+
+func NewPolicy(rules []PolicyRule) (*Policy, error) {
+	for i, rule := range rules {
+		if len(rule.Principals) == 0 && len(rule.Groups) == 0 {
+			return nil, fmt.Errorf("policy rule %d: principals or groups is required", i)
+		}
+		if len(rule.Queues) == 0 || len(rule.Verbs) == 0 {
+			return nil, fmt.Errorf("policy rule %d: queues and verbs are required", i)
+		}
+		for _, pattern := range rule.Queues {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("policy rule %d: invalid queue pattern %q: %w", i, pattern, err)
+			}
+		}
+	}
+
+	return &Policy{rules: rules}, nil
+}
+
+// Allowed reports whether p may perform verb on queue. A nil principal
+// (authentication disabled) only matches rules for the "*" principal.
+func (pol *Policy) Allowed(p *Principal, queue string, verb Verb) bool {
+	for _, rule := range pol.rules {
+		if rule.matchesPrincipal(p) && rule.matchesQueue(queue) && rule.matchesVerb(verb) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r PolicyRule) matchesPrincipal(p *Principal) bool {
+	if slices.Contains(r.Principals, wildcard) {
+		return true
+	}
+	if p == nil {
+		return false
+	}
+	if slices.Contains(r.Principals, p.ID) {
+		return true
+	}
+	for _, g := range p.Groups {
+		if slices.Contains(r.Groups, g) {
+			return true
+		}
+	}
+
+	return slices.Contains(r.Groups, wildcard)
+}
+
+func (r PolicyRule) matchesQueue(queue string) bool {
+	for _, pattern := range r.Queues {
+		if ok, _ := path.Match(pattern, queue); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r PolicyRule) matchesVerb(verb Verb) bool {
+	return slices.Contains(r.Verbs, verb) || slices.Contains(r.Verbs, wildcard)
+}
@@ -0,0 +1,59 @@
+// Package blobstore stores large task bodies outside of Redis.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/KasumiMercury/primind-tasks/internal/config"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a key/value store for payload bodies. Keys are slash-separated
+// paths made of URL-safe characters.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the blob; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// NewFromConfig builds the store selected by PAYLOAD_STORE. It returns nil
+// when offloading is disabled.
+func NewFromConfig(cfg *config.Config) (Store, error) {
+	switch cfg.PayloadStore {
+	case "":
+		return nil, nil
+	case "file":
+		return NewFileStore(cfg.PayloadStoreDir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:        cfg.PayloadStoreS3Endpoint,
+			Bucket:          cfg.PayloadStoreS3Bucket,
+			Region:          cfg.PayloadStoreS3Region,
+			AccessKeyID:     cfg.PayloadStoreS3AccessKeyID,
+			SecretAccessKey: cfg.PayloadStoreS3SecretAccessKey,
+			Prefix:          cfg.PayloadStoreS3Prefix,
+		})
+	default:
+		return nil, fmt.Errorf("unknown payload store %q", cfg.PayloadStore)
+	}
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+
+	return nil
+}
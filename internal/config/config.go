@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +17,83 @@ type Config struct {
 	WorkerConcurrency int
 	QueueName         string
 	RequestTimeout    time.Duration
+
+	QueueMetricsEnabled  bool
+	QueueMetricsInterval time.Duration
+
+	WorkerAdminEnabled bool
+	WorkerAdminPort    int
+
+	TraceLinkMode string
+
+	PayloadEncryptionKeys       string
+	PayloadEncryptionPrimaryKey string
+	PayloadEncryptedHeaders     []string
+
+	AuthAPIKeysFile    string
+	AuthJWKSFile       string
+	AuthJWTIssuer      string
+	AuthJWTAudience    string
+	AuthJWTGroupsClaim string
+	AuthPolicyFile     string
+
+	DestinationAllowHosts []string
+	DestinationDenyHosts  []string
+	DestinationAllowCIDRs []string
+	DestinationDenyCIDRs  []string
+
+	MaxRequestBytes  int64
+	MaxTaskBodyBytes int64
+
+	PayloadStore                  string
+	PayloadStoreThreshold         int
+	PayloadStoreDir               string
+	PayloadStoreS3Endpoint        string
+	PayloadStoreS3Bucket          string
+	PayloadStoreS3Region          string
+	PayloadStoreS3AccessKeyID     string
+	PayloadStoreS3SecretAccessKey string
+	PayloadStoreS3Prefix          string
+	PayloadStoreCollectInterval   time.Duration
+
+	PayloadCompression          string
+	PayloadCompressionThreshold int
+	PayloadForwardCompressed    bool
+
+	SchedulerSyncInterval time.Duration
+	SchedulerLeaderTTL    time.Duration
+
+	WorkflowRetention        time.Duration
+	WorkflowRecoveryInterval time.Duration
+
+	TaskResultRetention   time.Duration
+	ResponseRecordHeaders []string
+	ResponseRecordBody    int
+
+	AttemptHistoryLimit int
+	AttemptHistoryTTL   time.Duration
+
+	HistoryDBDriver   string
+	HistoryDBDSN      string
+	HistoryBufferSize int
+
+	OutboxDBDriver      string
+	OutboxDBDSN         string
+	OutboxAutoMigrate   bool
+	OutboxPollInterval  time.Duration
+	OutboxBatchSize     int
+	OutboxMaxAttempts   int
+	OutboxTaskRetention time.Duration
+
+	EventStreamMaxLen     int
+	EventStreamMaxClients int
+
+	WebhookSubscriptionsFile string
+
+	DedupQueues  []string
+	DedupWindow  time.Duration
+	DedupHeaders []string
+	DedupMode    string
 }
 
 func Load() *Config {
@@ -29,6 +107,83 @@ func Load() *Config {
 		WorkerConcurrency: getEnvInt("WORKER_CONCURRENCY", 10),
 		QueueName:         getEnv("QUEUE_NAME", "default"),
 		RequestTimeout:    getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+
+		QueueMetricsEnabled:  getEnvBool("QUEUE_METRICS_ENABLED", false),
+		QueueMetricsInterval: getEnvDuration("QUEUE_METRICS_INTERVAL", 15*time.Second),
+
+		WorkerAdminEnabled: getEnvBool("WORKER_ADMIN_ENABLED", false),
+		WorkerAdminPort:    getEnvInt("WORKER_ADMIN_PORT", 9090),
+
+		TraceLinkMode: getEnv("TRACE_LINK_MODE", "parent"),
+
+		PayloadEncryptionKeys:       getEnv("PAYLOAD_ENCRYPTION_KEYS", ""),
+		PayloadEncryptionPrimaryKey: getEnv("PAYLOAD_ENCRYPTION_PRIMARY_KEY", ""),
+		PayloadEncryptedHeaders:     getEnvList("PAYLOAD_ENCRYPTED_HEADERS", []string{"Authorization", "Proxy-Authorization", "Cookie"}),
+
+		AuthAPIKeysFile:    getEnv("AUTH_API_KEYS_FILE", ""),
+		AuthJWKSFile:       getEnv("AUTH_JWKS_FILE", ""),
+		AuthJWTIssuer:      getEnv("AUTH_JWT_ISSUER", ""),
+		AuthJWTAudience:    getEnv("AUTH_JWT_AUDIENCE", ""),
+		AuthJWTGroupsClaim: getEnv("AUTH_JWT_GROUPS_CLAIM", "groups"),
+		AuthPolicyFile:     getEnv("AUTH_POLICY_FILE", ""),
+
+		DestinationAllowHosts: getEnvList("DESTINATION_ALLOW_HOSTS", nil),
+		DestinationDenyHosts:  getEnvList("DESTINATION_DENY_HOSTS", nil),
+		DestinationAllowCIDRs: getEnvList("DESTINATION_ALLOW_CIDRS", nil),
+		DestinationDenyCIDRs:  getEnvList("DESTINATION_DENY_CIDRS", nil),
+
+		MaxRequestBytes:  int64(getEnvInt("MAX_REQUEST_BYTES", 2<<20)),
+		MaxTaskBodyBytes: int64(getEnvInt("MAX_TASK_BODY_BYTES", 1<<20)),
+
+		PayloadStore:                  getEnv("PAYLOAD_STORE", ""),
+		PayloadStoreThreshold:         getEnvInt("PAYLOAD_STORE_THRESHOLD_BYTES", 256<<10),
+		PayloadStoreDir:               getEnv("PAYLOAD_STORE_DIR", "/var/lib/primind-tasks/payloads"),
+		PayloadStoreS3Endpoint:        getEnv("PAYLOAD_STORE_S3_ENDPOINT", ""),
+		PayloadStoreS3Bucket:          getEnv("PAYLOAD_STORE_S3_BUCKET", ""),
+		PayloadStoreS3Region:          getEnv("PAYLOAD_STORE_S3_REGION", "us-east-1"),
+		PayloadStoreS3AccessKeyID:     getEnv("PAYLOAD_STORE_S3_ACCESS_KEY_ID", ""),
+		PayloadStoreS3SecretAccessKey: getEnv("PAYLOAD_STORE_S3_SECRET_ACCESS_KEY", ""),
+		PayloadStoreS3Prefix:          getEnv("PAYLOAD_STORE_S3_PREFIX", ""),
+		PayloadStoreCollectInterval:   getEnvDuration("PAYLOAD_STORE_COLLECT_INTERVAL", 5*time.Minute),
+
+		PayloadCompression:          getEnv("PAYLOAD_COMPRESSION", ""),
+		PayloadCompressionThreshold: getEnvInt("PAYLOAD_COMPRESSION_THRESHOLD_BYTES", 1024),
+		PayloadForwardCompressed:    getEnvBool("PAYLOAD_FORWARD_COMPRESSED", false),
+
+		SchedulerSyncInterval: getEnvDuration("SCHEDULER_SYNC_INTERVAL", 30*time.Second),
+		SchedulerLeaderTTL:    getEnvDuration("SCHEDULER_LEADER_TTL", 15*time.Second),
+
+		WorkflowRetention:        getEnvDuration("WORKFLOW_RETENTION", 7*24*time.Hour),
+		WorkflowRecoveryInterval: getEnvDuration("WORKFLOW_RECOVERY_INTERVAL", time.Minute),
+
+		TaskResultRetention:   getEnvDuration("TASK_RESULT_RETENTION", 0),
+		ResponseRecordHeaders: getEnvList("RESPONSE_RECORD_HEADERS", []string{"Content-Type", "Retry-After", "X-Request-Id"}),
+		ResponseRecordBody:    getEnvInt("RESPONSE_RECORD_BODY_BYTES", 4096),
+
+		AttemptHistoryLimit: getEnvInt("ATTEMPT_HISTORY_LIMIT", 100),
+		AttemptHistoryTTL:   getEnvDuration("ATTEMPT_HISTORY_TTL", 7*24*time.Hour),
+
+		HistoryDBDriver:   getEnv("HISTORY_DB_DRIVER", ""),
+		HistoryDBDSN:      getEnv("HISTORY_DB_DSN", "primind-history.db"),
+		HistoryBufferSize: getEnvInt("HISTORY_BUFFER_SIZE", 10000),
+
+		OutboxDBDriver:      getEnv("OUTBOX_DB_DRIVER", ""),
+		OutboxDBDSN:         getEnv("OUTBOX_DB_DSN", ""),
+		OutboxAutoMigrate:   getEnvBool("OUTBOX_AUTO_MIGRATE", false),
+		OutboxPollInterval:  getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxBatchSize:     getEnvInt("OUTBOX_BATCH_SIZE", 100),
+		OutboxMaxAttempts:   getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+		OutboxTaskRetention: getEnvDuration("OUTBOX_TASK_RETENTION", 24*time.Hour),
+
+		EventStreamMaxLen:     getEnvInt("EVENT_STREAM_MAX_LEN", 10000),
+		EventStreamMaxClients: getEnvInt("EVENT_STREAM_MAX_CLIENTS", 100),
+
+		WebhookSubscriptionsFile: getEnv("WEBHOOK_SUBSCRIPTIONS_FILE", ""),
+
+		DedupQueues:  getEnvList("DEDUP_QUEUES", nil),
+		DedupWindow:  getEnvDuration("DEDUP_WINDOW", 10*time.Minute),
+		DedupHeaders: getEnvList("DEDUP_HEADERS", nil),
+		DedupMode:    getEnv("DEDUP_MODE", "reject"),
 	}
 }
 
@@ -48,6 +203,28 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
+func getEnvList(key string, defaultVal []string) []string {
+	if val := os.Getenv(key); val != "" {
+		var list []string
+		for _, item := range strings.Split(val, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	return defaultVal
+}
+
 func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
 	if val := os.Getenv(key); val != "" {
 		if d, err := time.ParseDuration(val); err == nil {
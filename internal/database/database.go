@@ -0,0 +1,50 @@
+// Package database opens the SQL databases used through GORM.
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
+)
+
+// Supported drivers.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// slowQueryThreshold is reported by the GORM logger as a slow query.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// Open connects to a SQLite (dsn is a file path) or Postgres database,
+// logging queries through slog.
+func Open(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverSQLite:
+		dialector = sqlite.Open(dsn)
+	case DriverPostgres:
+		dialector = postgres.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	return gorm.Open(dialector, &gorm.Config{
+		Logger: logging.NewGormLogger(slowQueryThreshold),
+	})
+}
+
+// Close closes the connection pool of db.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
@@ -0,0 +1,175 @@
+// Package envelope implements envelope encryption with AES-GCM: each message
+// is encrypted with a fresh data key, which is in turn wrapped with a
+// locally configured key-encryption key identified by a key ID.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const keySize = 32 // AES-256
+
+var (
+	ErrUnknownKey       = errors.New("unknown encryption key id")
+	ErrMalformedMessage = errors.New("malformed encrypted message")
+)
+
+// Envelope is an encrypted message together with its wrapped data key.
+type Envelope struct {
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keyring holds key-encryption keys by ID. New messages are sealed with the
+// primary key; older keys are kept to open messages sealed before rotation.
+type Keyring struct {
+	keys    map[string][]byte
+	primary string
+}
+
+func NewKeyring(keys map[string][]byte, primary string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one encryption key is required")
+	}
+
+	for id, key := range keys {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("encryption key %q must be %d bytes, got %d", id, keySize, len(key))
+		}
+	}
+
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary encryption key %q is not configured", primary)
+	}
+
+	return &Keyring{keys: keys, primary: primary}, nil
+}
+
+// LoadKeyring parses spec ("id:base64key,id:base64key") into a keyring.
+// It returns nil without error when spec is empty (encryption disabled).
+// When primary is empty, the first key in spec is used.
+func LoadKeyring(spec, primary string) (*Keyring, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(spec, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" || encoded == "" {
+			return nil, fmt.Errorf("invalid encryption key entry %q: expected id:base64key", entry)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+		}
+
+		keys[id] = key
+		if primary == "" {
+			primary = id
+		}
+	}
+
+	return NewKeyring(keys, primary)
+}
+
+// PrimaryKeyID returns the ID of the key used to seal new messages.
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Seal encrypts plaintext with a fresh data key wrapped by the primary key.
+// The ciphertext is bound to the key ID and to bindings, which Open must be
+// given again, so an envelope cannot be moved to another context.
+func (k *Keyring) Seal(plaintext []byte, bindings ...string) (*Envelope, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := encrypt(dataKey, plaintext, additionalData(k.primary, bindings))
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := encrypt(k.keys[k.primary], dataKey, []byte(k.primary))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		KeyID:      k.primary,
+		WrappedKey: wrappedKey,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// Open decrypts an envelope sealed with any key in the keyring and the same
+// bindings. Envelopes sealed without bindings are opened with none.
+func (k *Keyring) Open(e *Envelope, bindings ...string) ([]byte, error) {
+	kek, ok := k.keys[e.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, e.KeyID)
+	}
+
+	dataKey, err := decrypt(kek, e.WrappedKey, []byte(e.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	return decrypt(dataKey, e.Ciphertext, additionalData(e.KeyID, bindings))
+}
+
+// additionalData joins the key ID and bindings with NUL separators, which
+// cannot appear in queue names, task IDs or blob keys.
+func additionalData(keyID string, bindings []string) []byte {
+	return []byte(strings.Join(append([]string{keyID}, bindings...), "\x00"))
+}
+
+// encrypt returns nonce || AES-GCM ciphertext.
+func encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key, data, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, ErrMalformedMessage
+	}
+
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
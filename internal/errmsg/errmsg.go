@@ -0,0 +1,17 @@
+// Package errmsg bounds the error messages kept in stores and streams.
+package errmsg
+
+import "strings"
+
+// MaxLength bounds a kept error message in bytes.
+const MaxLength = 1024
+
+// Truncate cuts msg to MaxLength bytes. A multi-byte character split by the
+// cut is dropped, since Postgres and JSON encoding reject invalid UTF-8.
+func Truncate(msg string) string {
+	if len(msg) <= MaxLength {
+		return msg
+	}
+
+	return strings.ToValidUTF8(msg[:MaxLength], "")
+}
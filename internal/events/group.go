@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// EnsureGroup creates a consumer group on the stream of a queue, starting at
+// events published from now on. An existing group is left as is.
+func (s *Stream) EnsureGroup(ctx context.Context, queueName, group string) error {
+	err := s.rdb.XGroupCreateMkStream(ctx, streamKey(queueName), group, "$").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+
+	return err
+}
+
+// ReadGroup returns up to count events of the queues not yet delivered to
+// group, waiting up to block for one to arrive. The events stay pending for
+// consumer until they are acknowledged with Ack.
+func (s *Stream) ReadGroup(ctx context.Context, group, consumer string, queueNames []string, count int64, block time.Duration) ([]Event, error) {
+	keys := make(map[string]string, len(queueNames))
+	args := make([]string, 0, 2*len(queueNames))
+	for _, name := range queueNames {
+		key := streamKey(name)
+		keys[key] = name
+		args = append(args, key)
+	}
+	for range queueNames {
+		args = append(args, ">")
+	}
+
+	streams, err := s.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  args,
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStreams(streams, keys), nil
+}
+
+// Claim transfers to consumer up to count events of a queue that were
+// delivered to group but not acknowledged for at least minIdle, such as
+// events read by a consumer that crashed.
+func (s *Stream) Claim(ctx context.Context, queueName, group, consumer string, minIdle time.Duration, count int64) ([]Event, error) {
+	msgs, _, err := s.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   streamKey(queueName),
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(msgs))
+	for _, msg := range msgs {
+		events = append(events, parseEvent(queueName, msg))
+	}
+
+	return events, nil
+}
+
+// Ack acknowledges that group has handled the events with the given IDs.
+func (s *Stream) Ack(ctx context.Context, queueName, group string, ids ...string) error {
+	return s.rdb.XAck(ctx, streamKey(queueName), group, ids...).Err()
+}
@@ -0,0 +1,194 @@
+// Package events publishes task lifecycle events to per-queue Redis streams
+// for live consumers such as dashboards.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/KasumiMercury/primind-tasks/internal/errmsg"
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+)
+
+// Event types.
+const (
+	TypeEnqueued  = "ENQUEUED"
+	TypeStarted   = "STARTED"
+	TypeSucceeded = "SUCCEEDED"
+	TypeRetried   = "RETRIED"
+	TypeFailed    = "FAILED"
+	TypeDeleted   = "DELETED"
+)
+
+// Types lists all event types.
+var Types = []string{TypeEnqueued, TypeStarted, TypeSucceeded, TypeRetried, TypeFailed, TypeDeleted}
+
+// keyPrefix prefixes the Redis stream holding the events of a queue.
+const keyPrefix = "primind:events:"
+
+// idPattern matches Redis stream entry IDs.
+var idPattern = regexp.MustCompile(`^[0-9]+-[0-9]+$`)
+
+// Event is a task lifecycle event.
+type Event struct {
+	// ID is the stream entry ID, set on events returned by Read
+	ID             string
+	Type           string
+	TaskID         string
+	Queue          string
+	Time           time.Time
+	AttemptNumber  int
+	ResponseStatus int
+	Error          string
+	Worker         string
+}
+
+// ToProto converts ev to its API representation.
+func (ev Event) ToProto() *taskqueuev1.TaskEvent {
+	return &taskqueuev1.TaskEvent{
+		Id:             ev.ID,
+		Name:           ev.TaskID,
+		Queue:          ev.Queue,
+		Type:           ev.Type,
+		Time:           ev.Time.Format(time.RFC3339),
+		AttemptNumber:  int32(ev.AttemptNumber),
+		ResponseStatus: int32(ev.ResponseStatus),
+		Error:          ev.Error,
+		Worker:         ev.Worker,
+	}
+}
+
+// Stream appends events to a per-queue Redis stream capped at about maxLen
+// entries. A nil Stream discards all events.
+type Stream struct {
+	rdb    redis.UniversalClient
+	maxLen int64
+}
+
+func NewStream(rdb redis.UniversalClient, maxLen int64) *Stream {
+	return &Stream{
+		rdb:    rdb,
+		maxLen: maxLen,
+	}
+}
+
+func streamKey(queueName string) string {
+	return fmt.Sprintf("%s{%s}", keyPrefix, queueName)
+}
+
+// ValidID reports whether id is a stream entry ID, as sent in Last-Event-ID.
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Publish appends ev to the stream of its queue. Events only feed live
+// consumers, so failures are logged and otherwise ignored.
+func (s *Stream) Publish(ctx context.Context, ev Event) {
+	if s == nil {
+		return
+	}
+
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	ev.Error = errmsg.Truncate(ev.Error)
+
+	err := s.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(ev.Queue),
+		MaxLen: s.maxLen,
+		Approx: true,
+		Values: map[string]any{
+			"type":            ev.Type,
+			"task_id":         ev.TaskID,
+			"time":            ev.Time.UTC().Format(time.RFC3339Nano),
+			"attempt_number":  ev.AttemptNumber,
+			"response_status": ev.ResponseStatus,
+			"error":           ev.Error,
+			"worker":          ev.Worker,
+		},
+	}).Err()
+	if err != nil {
+		slog.WarnContext(ctx, "failed to publish task event",
+			slog.String("event", "events.publish.fail"),
+			slog.String("task_id", ev.TaskID),
+			slog.String("events.type", ev.Type),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// LastID returns the ID of the newest event of a queue, or "0-0" when the
+// stream is empty. Reading after it yields only events published later.
+func (s *Stream) LastID(ctx context.Context, queueName string) (string, error) {
+	entries, err := s.rdb.XRevRangeN(ctx, streamKey(queueName), "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "0-0", nil
+	}
+
+	return entries[0].ID, nil
+}
+
+// Read returns up to count events of a queue published after the event with
+// ID after, waiting up to block for one to arrive. It returns no events when
+// none arrived in time.
+func (s *Stream) Read(ctx context.Context, queueName, after string, count int64, block time.Duration) ([]Event, error) {
+	streams, err := s.rdb.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{streamKey(queueName), after},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStreams(streams, map[string]string{streamKey(queueName): queueName}), nil
+}
+
+func parseStreams(streams []redis.XStream, queueNames map[string]string) []Event {
+	var events []Event
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			events = append(events, parseEvent(queueNames[stream.Stream], msg))
+		}
+	}
+
+	return events
+}
+
+func parseEvent(queueName string, msg redis.XMessage) Event {
+	str := func(name string) string {
+		v, _ := msg.Values[name].(string)
+		return v
+	}
+	num := func(name string) int {
+		n, _ := strconv.Atoi(str(name))
+		return n
+	}
+
+	t, _ := time.Parse(time.RFC3339Nano, str("time"))
+
+	return Event{
+		ID:             msg.ID,
+		Type:           str("type"),
+		TaskID:         str("task_id"),
+		Queue:          queueName,
+		Time:           t,
+		AttemptNumber:  num("attempt_number"),
+		ResponseStatus: num("response_status"),
+		Error:          str("error"),
+		Worker:         str("worker"),
+	}
+}
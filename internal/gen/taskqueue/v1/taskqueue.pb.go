@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.10
+// 	protoc-gen-go v1.36.11
 // 	protoc        (unknown)
 // source: taskqueue/v1/taskqueue.proto
 
@@ -27,8 +27,10 @@ const (
 type HTTPRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Base64-encoded body payload
-	Body          string            `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
-	Headers       map[string]string `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Body    string            `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
+	Headers map[string]string `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Absolute http(s) URL to send the request to (optional, worker TARGET_ENDPOINT by default)
+	Url           string `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -77,14 +79,35 @@ func (x *HTTPRequest) GetHeaders() map[string]string {
 	return nil
 }
 
+func (x *HTTPRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
 // Task represents a task to be queued
 type Task struct {
 	state       protoimpl.MessageState `protogen:"open.v1"`
 	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	HttpRequest *HTTPRequest           `protobuf:"bytes,2,opt,name=http_request,json=httpRequest,proto3" json:"http_request,omitempty"`
 	// RFC3339 formatted schedule time (optional, for delayed execution)
-	ScheduleTime  string `protobuf:"bytes,3,opt,name=schedule_time,json=scheduleTime,proto3" json:"schedule_time,omitempty"`
-	CreateTime    string `protobuf:"bytes,4,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	ScheduleTime string `protobuf:"bytes,3,opt,name=schedule_time,json=scheduleTime,proto3" json:"schedule_time,omitempty"`
+	CreateTime   string `protobuf:"bytes,4,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	// Task enqueued into the same queue after this task succeeds (optional)
+	OnSuccess *Task `protobuf:"bytes,5,opt,name=on_success,json=onSuccess,proto3" json:"on_success,omitempty"`
+	// Task enqueued into the same queue after this task finally fails (optional)
+	OnFailure *Task `protobuf:"bytes,6,opt,name=on_failure,json=onFailure,proto3" json:"on_failure,omitempty"`
+	// PENDING, SCHEDULED, ACTIVE, RETRY, ARCHIVED or COMPLETED (output only)
+	State string `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"`
+	// Number of retries so far (output only)
+	RetryCount int32 `protobuf:"varint,8,opt,name=retry_count,json=retryCount,proto3" json:"retry_count,omitempty"`
+	// Outcome of the latest dispatch attempt (output only)
+	LastAttempt *Attempt `protobuf:"bytes,9,opt,name=last_attempt,json=lastAttempt,proto3" json:"last_attempt,omitempty"`
+	// Labels for searching and batch operations, e.g. user_id: 123 (optional)
+	Labels map[string]string `protobuf:"bytes,10,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Version of the task; an update carrying it is rejected if the task changed since (output only)
+	Etag          string `protobuf:"bytes,11,opt,name=etag,proto3" json:"etag,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -147,6 +170,55 @@ func (x *Task) GetCreateTime() string {
 	return ""
 }
 
+func (x *Task) GetOnSuccess() *Task {
+	if x != nil {
+		return x.OnSuccess
+	}
+	return nil
+}
+
+func (x *Task) GetOnFailure() *Task {
+	if x != nil {
+		return x.OnFailure
+	}
+	return nil
+}
+
+func (x *Task) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Task) GetRetryCount() int32 {
+	if x != nil {
+		return x.RetryCount
+	}
+	return 0
+}
+
+func (x *Task) GetLastAttempt() *Attempt {
+	if x != nil {
+		return x.LastAttempt
+	}
+	return nil
+}
+
+func (x *Task) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Task) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
 // CreateTaskRequest is sent from central-backend or throttling to primind-tasks
 type CreateTaskRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -338,10 +410,28 @@ func (*DeleteTaskResponse) Descriptor() ([]byte, []int) {
 
 // TaskPayload is the internal payload structure stored in the queue
 type TaskPayload struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Body          []byte                 `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
-	Headers       map[string]string      `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Body      []byte                 `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
+	Headers   map[string]string      `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Encrypted body and sensitive headers; body is empty while set
+	Sealed *SealedEnvelope `protobuf:"bytes,4,opt,name=sealed,proto3" json:"sealed,omitempty"`
+	// Reference to a body offloaded to the payload store; body is empty while set
+	BodyRef *PayloadBodyRef `protobuf:"bytes,5,opt,name=body_ref,json=bodyRef,proto3" json:"body_ref,omitempty"`
+	// Compression applied to body ("gzip", "zstd"); empty when uncompressed
+	BodyEncoding string `protobuf:"bytes,6,opt,name=body_encoding,json=bodyEncoding,proto3" json:"body_encoding,omitempty"`
+	// Destination URL; empty means the worker's target endpoint
+	Url string `protobuf:"bytes,7,opt,name=url,proto3" json:"url,omitempty"`
+	// Encoded TaskPayload enqueued after the task succeeds
+	OnSuccess []byte `protobuf:"bytes,8,opt,name=on_success,json=onSuccess,proto3" json:"on_success,omitempty"`
+	// Encoded TaskPayload enqueued after the task finally fails
+	OnFailure []byte `protobuf:"bytes,9,opt,name=on_failure,json=onFailure,proto3" json:"on_failure,omitempty"`
+	// Workflow this task runs a node of; empty for standalone tasks
+	WorkflowId string `protobuf:"bytes,10,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	// Workflow node ID this task runs
+	WorkflowNode string `protobuf:"bytes,11,opt,name=workflow_node,json=workflowNode,proto3" json:"workflow_node,omitempty"`
+	// Labels of the task, indexed in Redis by queue
+	Labels        map[string]string `protobuf:"bytes,12,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -397,6 +487,69 @@ func (x *TaskPayload) GetCreatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *TaskPayload) GetSealed() *SealedEnvelope {
+	if x != nil {
+		return x.Sealed
+	}
+	return nil
+}
+
+func (x *TaskPayload) GetBodyRef() *PayloadBodyRef {
+	if x != nil {
+		return x.BodyRef
+	}
+	return nil
+}
+
+func (x *TaskPayload) GetBodyEncoding() string {
+	if x != nil {
+		return x.BodyEncoding
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetOnSuccess() []byte {
+	if x != nil {
+		return x.OnSuccess
+	}
+	return nil
+}
+
+func (x *TaskPayload) GetOnFailure() []byte {
+	if x != nil {
+		return x.OnFailure
+	}
+	return nil
+}
+
+func (x *TaskPayload) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetWorkflowNode() string {
+	if x != nil {
+		return x.WorkflowNode
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
 // ErrorResponse is the standard error response for taskqueue service
 type ErrorResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -458,45 +611,1448 @@ func (x *ErrorResponse) GetMessage() string {
 	return ""
 }
 
-var File_taskqueue_v1_taskqueue_proto protoreflect.FileDescriptor
+// SealedEnvelope is an AES-GCM encrypted message with its wrapped data key
+type SealedEnvelope struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyId         string                 `protobuf:"bytes,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	WrappedKey    []byte                 `protobuf:"bytes,2,opt,name=wrapped_key,json=wrappedKey,proto3" json:"wrapped_key,omitempty"`
+	Ciphertext    []byte                 `protobuf:"bytes,3,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_taskqueue_v1_taskqueue_proto_rawDesc = "" +
-	"\n" +
-	"\x1ctaskqueue/v1/taskqueue.proto\x12\ftaskqueue.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9f\x01\n" +
-	"\vHTTPRequest\x12\x12\n" +
-	"\x04body\x18\x01 \x01(\tR\x04body\x12@\n" +
-	"\aheaders\x18\x02 \x03(\v2&.taskqueue.v1.HTTPRequest.HeadersEntryR\aheaders\x1a:\n" +
-	"\fHeadersEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa6\x01\n" +
-	"\x04Task\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12D\n" +
-	"\fhttp_request\x18\x02 \x01(\v2\x19.taskqueue.v1.HTTPRequestB\x06\xbaH\x03\xc8\x01\x01R\vhttpRequest\x12#\n" +
-	"\rschedule_time\x18\x03 \x01(\tR\fscheduleTime\x12\x1f\n" +
-	"\vcreate_time\x18\x04 \x01(\tR\n" +
-	"createTime\"C\n" +
-	"\x11CreateTaskRequest\x12.\n" +
-	"\x04task\x18\x01 \x01(\v2\x12.taskqueue.v1.TaskB\x06\xbaH\x03\xc8\x01\x01R\x04task\"n\n" +
-	"\x12CreateTaskResponse\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
-	"\rschedule_time\x18\x02 \x01(\tR\fscheduleTime\x12\x1f\n" +
-	"\vcreate_time\x18\x03 \x01(\tR\n" +
-	"createTime\"/\n" +
-	"\x11DeleteTaskRequest\x12\x1a\n" +
-	"\x04name\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x04name\"\x14\n" +
-	"\x12DeleteTaskResponse\"\xda\x01\n" +
-	"\vTaskPayload\x12\x12\n" +
-	"\x04body\x18\x01 \x01(\fR\x04body\x12@\n" +
-	"\aheaders\x18\x02 \x03(\v2&.taskqueue.v1.TaskPayload.HeadersEntryR\aheaders\x129\n" +
-	"\n" +
-	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x1a:\n" +
-	"\fHeadersEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"U\n" +
-	"\rErrorResponse\x12\x12\n" +
-	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessageB\xc1\x01\n" +
+func (x *SealedEnvelope) Reset() {
+	*x = SealedEnvelope{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SealedEnvelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SealedEnvelope) ProtoMessage() {}
+
+func (x *SealedEnvelope) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SealedEnvelope.ProtoReflect.Descriptor instead.
+func (*SealedEnvelope) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SealedEnvelope) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *SealedEnvelope) GetWrappedKey() []byte {
+	if x != nil {
+		return x.WrappedKey
+	}
+	return nil
+}
+
+func (x *SealedEnvelope) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+// PayloadBodyRef references a task body kept in the payload store
+type PayloadBodyRef struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Size  int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// Whether the stored blob is a SealedEnvelope
+	Sealed        bool `protobuf:"varint,3,opt,name=sealed,proto3" json:"sealed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PayloadBodyRef) Reset() {
+	*x = PayloadBodyRef{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PayloadBodyRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PayloadBodyRef) ProtoMessage() {}
+
+func (x *PayloadBodyRef) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PayloadBodyRef.ProtoReflect.Descriptor instead.
+func (*PayloadBodyRef) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PayloadBodyRef) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PayloadBodyRef) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *PayloadBodyRef) GetSealed() bool {
+	if x != nil {
+		return x.Sealed
+	}
+	return false
+}
+
+// Schedule enqueues a task from a template on a cron schedule
+type Schedule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Schedule ID (optional, generated when empty)
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Cron expression with five fields, e.g. "0 9 * * 1" (required)
+	Schedule string `protobuf:"bytes,2,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	// IANA time zone the cron expression is evaluated in (optional, UTC by default)
+	TimeZone string `protobuf:"bytes,3,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"`
+	// Queue to enqueue into (optional, default queue when empty)
+	Queue string `protobuf:"bytes,4,opt,name=queue,proto3" json:"queue,omitempty"`
+	// Task template; name and schedule_time are ignored
+	Task *Task `protobuf:"bytes,5,opt,name=task,proto3" json:"task,omitempty"`
+	// ENABLED or PAUSED (output only)
+	State         string `protobuf:"bytes,6,opt,name=state,proto3" json:"state,omitempty"`
+	CreateTime    string `protobuf:"bytes,7,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	UpdateTime    string `protobuf:"bytes,8,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Schedule) Reset() {
+	*x = Schedule{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schedule) ProtoMessage() {}
+
+func (x *Schedule) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schedule.ProtoReflect.Descriptor instead.
+func (*Schedule) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Schedule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Schedule) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *Schedule) GetTimeZone() string {
+	if x != nil {
+		return x.TimeZone
+	}
+	return ""
+}
+
+func (x *Schedule) GetQueue() string {
+	if x != nil {
+		return x.Queue
+	}
+	return ""
+}
+
+func (x *Schedule) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+func (x *Schedule) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Schedule) GetCreateTime() string {
+	if x != nil {
+		return x.CreateTime
+	}
+	return ""
+}
+
+func (x *Schedule) GetUpdateTime() string {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return ""
+}
+
+// CreateScheduleRequest registers a recurring task
+type CreateScheduleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedule      *Schedule              `protobuf:"bytes,1,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateScheduleRequest) Reset() {
+	*x = CreateScheduleRequest{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateScheduleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateScheduleRequest) ProtoMessage() {}
+
+func (x *CreateScheduleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateScheduleRequest.ProtoReflect.Descriptor instead.
+func (*CreateScheduleRequest) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CreateScheduleRequest) GetSchedule() *Schedule {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+// ListSchedulesResponse lists the registered schedules
+type ListSchedulesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedules     []*Schedule            `protobuf:"bytes,1,rep,name=schedules,proto3" json:"schedules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSchedulesResponse) Reset() {
+	*x = ListSchedulesResponse{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSchedulesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSchedulesResponse) ProtoMessage() {}
+
+func (x *ListSchedulesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSchedulesResponse.ProtoReflect.Descriptor instead.
+func (*ListSchedulesResponse) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListSchedulesResponse) GetSchedules() []*Schedule {
+	if x != nil {
+		return x.Schedules
+	}
+	return nil
+}
+
+// DeleteScheduleResponse is empty on success
+type DeleteScheduleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteScheduleResponse) Reset() {
+	*x = DeleteScheduleResponse{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteScheduleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteScheduleResponse) ProtoMessage() {}
+
+func (x *DeleteScheduleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteScheduleResponse.ProtoReflect.Descriptor instead.
+func (*DeleteScheduleResponse) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{13}
+}
+
+// Workflow runs a DAG of task templates, enqueueing each node once its dependencies succeed
+type Workflow struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Workflow ID (optional, generated when empty)
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Queue the nodes are enqueued into (optional, default queue when empty)
+	Queue string `protobuf:"bytes,2,opt,name=queue,proto3" json:"queue,omitempty"`
+	// Nodes of the DAG (required)
+	Nodes []*WorkflowNode `protobuf:"bytes,3,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	// RUNNING, SUCCEEDED, FAILED or CANCELLED (output only)
+	State         string `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	CreateTime    string `protobuf:"bytes,5,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	UpdateTime    string `protobuf:"bytes,6,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Workflow) Reset() {
+	*x = Workflow{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Workflow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Workflow) ProtoMessage() {}
+
+func (x *Workflow) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Workflow.ProtoReflect.Descriptor instead.
+func (*Workflow) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Workflow) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Workflow) GetQueue() string {
+	if x != nil {
+		return x.Queue
+	}
+	return ""
+}
+
+func (x *Workflow) GetNodes() []*WorkflowNode {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *Workflow) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Workflow) GetCreateTime() string {
+	if x != nil {
+		return x.CreateTime
+	}
+	return ""
+}
+
+func (x *Workflow) GetUpdateTime() string {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return ""
+}
+
+// WorkflowNode is a task template with the nodes it depends on
+type WorkflowNode struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Node ID, unique within the workflow (required)
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// IDs of the nodes that must succeed before this node is enqueued
+	DependsOn []string `protobuf:"bytes,2,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
+	// Task template; name and schedule_time are ignored
+	Task *Task `protobuf:"bytes,3,opt,name=task,proto3" json:"task,omitempty"`
+	// PENDING, ENQUEUED, SUCCEEDED, FAILED or CANCELLED (output only)
+	State string `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	// ID of the task of the latest attempt (output only)
+	TaskId string `protobuf:"bytes,5,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	// Error of the latest failed attempt (output only)
+	Error         string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkflowNode) Reset() {
+	*x = WorkflowNode{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkflowNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkflowNode) ProtoMessage() {}
+
+func (x *WorkflowNode) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkflowNode.ProtoReflect.Descriptor instead.
+func (*WorkflowNode) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WorkflowNode) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WorkflowNode) GetDependsOn() []string {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+func (x *WorkflowNode) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+func (x *WorkflowNode) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *WorkflowNode) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *WorkflowNode) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// CreateWorkflowRequest submits a workflow
+type CreateWorkflowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workflow      *Workflow              `protobuf:"bytes,1,opt,name=workflow,proto3" json:"workflow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWorkflowRequest) Reset() {
+	*x = CreateWorkflowRequest{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWorkflowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWorkflowRequest) ProtoMessage() {}
+
+func (x *CreateWorkflowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWorkflowRequest.ProtoReflect.Descriptor instead.
+func (*CreateWorkflowRequest) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CreateWorkflowRequest) GetWorkflow() *Workflow {
+	if x != nil {
+		return x.Workflow
+	}
+	return nil
+}
+
+// Attempt describes a dispatch of a task to its target
+type Attempt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// RFC3339 time the request was sent
+	DispatchTime string `protobuf:"bytes,1,opt,name=dispatch_time,json=dispatchTime,proto3" json:"dispatch_time,omitempty"`
+	// RFC3339 time the response or error was received
+	ResponseTime string `protobuf:"bytes,2,opt,name=response_time,json=responseTime,proto3" json:"response_time,omitempty"`
+	// HTTP status code; 0 when no response was received
+	ResponseStatus int32 `protobuf:"varint,3,opt,name=response_status,json=responseStatus,proto3" json:"response_status,omitempty"`
+	// Recorded subset of the response headers
+	ResponseHeaders map[string]string `protobuf:"bytes,4,rep,name=response_headers,json=responseHeaders,proto3" json:"response_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Response body, truncated to the configured limit
+	ResponseBody []byte `protobuf:"bytes,5,opt,name=response_body,json=responseBody,proto3" json:"response_body,omitempty"`
+	// Whether response_body was truncated
+	ResponseBodyTruncated bool `protobuf:"varint,6,opt,name=response_body_truncated,json=responseBodyTruncated,proto3" json:"response_body_truncated,omitempty"`
+	// Error of a failed attempt
+	Error string `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	// Time from dispatch to response or error in milliseconds
+	DurationMs int64 `protobuf:"varint,8,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	// Failure reason (client_error, server_error, http_error, destination_denied); empty on success
+	Reason string `protobuf:"bytes,9,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Worker instance that dispatched the task
+	Worker string `protobuf:"bytes,10,opt,name=worker,proto3" json:"worker,omitempty"`
+	// 1 for the first dispatch, incremented on every retry
+	AttemptNumber int32 `protobuf:"varint,11,opt,name=attempt_number,json=attemptNumber,proto3" json:"attempt_number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attempt) Reset() {
+	*x = Attempt{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attempt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attempt) ProtoMessage() {}
+
+func (x *Attempt) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attempt.ProtoReflect.Descriptor instead.
+func (*Attempt) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Attempt) GetDispatchTime() string {
+	if x != nil {
+		return x.DispatchTime
+	}
+	return ""
+}
+
+func (x *Attempt) GetResponseTime() string {
+	if x != nil {
+		return x.ResponseTime
+	}
+	return ""
+}
+
+func (x *Attempt) GetResponseStatus() int32 {
+	if x != nil {
+		return x.ResponseStatus
+	}
+	return 0
+}
+
+func (x *Attempt) GetResponseHeaders() map[string]string {
+	if x != nil {
+		return x.ResponseHeaders
+	}
+	return nil
+}
+
+func (x *Attempt) GetResponseBody() []byte {
+	if x != nil {
+		return x.ResponseBody
+	}
+	return nil
+}
+
+func (x *Attempt) GetResponseBodyTruncated() bool {
+	if x != nil {
+		return x.ResponseBodyTruncated
+	}
+	return false
+}
+
+func (x *Attempt) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Attempt) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *Attempt) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Attempt) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+func (x *Attempt) GetAttemptNumber() int32 {
+	if x != nil {
+		return x.AttemptNumber
+	}
+	return 0
+}
+
+// ListAttemptsResponse lists the recorded attempts of a task, oldest first
+type ListAttemptsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attempts      []*Attempt             `protobuf:"bytes,1,rep,name=attempts,proto3" json:"attempts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAttemptsResponse) Reset() {
+	*x = ListAttemptsResponse{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAttemptsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAttemptsResponse) ProtoMessage() {}
+
+func (x *ListAttemptsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAttemptsResponse.ProtoReflect.Descriptor instead.
+func (*ListAttemptsResponse) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListAttemptsResponse) GetAttempts() []*Attempt {
+	if x != nil {
+		return x.Attempts
+	}
+	return nil
+}
+
+// HistoryEvent is a task lifecycle event kept in the history database
+type HistoryEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Task ID
+	Name  string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Queue string `protobuf:"bytes,3,opt,name=queue,proto3" json:"queue,omitempty"`
+	// CREATED, DISPATCHED, SUCCEEDED, FAILED or DELETED
+	Type string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	// RFC3339 time of the event
+	Time string `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	// HTTP status code of a dispatch; 0 when no response was received
+	ResponseStatus int32 `protobuf:"varint,6,opt,name=response_status,json=responseStatus,proto3" json:"response_status,omitempty"`
+	// Failure reason of a dispatch or failure
+	Reason string `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	Error  string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	// Dispatch attempt the event belongs to
+	AttemptNumber int32 `protobuf:"varint,9,opt,name=attempt_number,json=attemptNumber,proto3" json:"attempt_number,omitempty"`
+	// Worker instance that dispatched the task
+	Worker        string `protobuf:"bytes,10,opt,name=worker,proto3" json:"worker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryEvent) Reset() {
+	*x = HistoryEvent{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryEvent) ProtoMessage() {}
+
+func (x *HistoryEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryEvent.ProtoReflect.Descriptor instead.
+func (*HistoryEvent) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HistoryEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *HistoryEvent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HistoryEvent) GetQueue() string {
+	if x != nil {
+		return x.Queue
+	}
+	return ""
+}
+
+func (x *HistoryEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *HistoryEvent) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *HistoryEvent) GetResponseStatus() int32 {
+	if x != nil {
+		return x.ResponseStatus
+	}
+	return 0
+}
+
+func (x *HistoryEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *HistoryEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *HistoryEvent) GetAttemptNumber() int32 {
+	if x != nil {
+		return x.AttemptNumber
+	}
+	return 0
+}
+
+func (x *HistoryEvent) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+// ListHistoryResponse lists history events, newest first
+type ListHistoryResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Events []*HistoryEvent        `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	// Token for the next page; empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHistoryResponse) Reset() {
+	*x = ListHistoryResponse{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHistoryResponse) ProtoMessage() {}
+
+func (x *ListHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHistoryResponse.ProtoReflect.Descriptor instead.
+func (*ListHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListHistoryResponse) GetEvents() []*HistoryEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ListHistoryResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// TaskEvent is a live task lifecycle event sent on the event stream
+type TaskEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Stream entry ID, usable as Last-Event-ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Task ID
+	Name  string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Queue string `protobuf:"bytes,3,opt,name=queue,proto3" json:"queue,omitempty"`
+	// ENQUEUED, STARTED, SUCCEEDED, RETRIED, FAILED or DELETED
+	Type string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	// RFC3339 time of the event
+	Time string `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	// Dispatch attempt the event belongs to
+	AttemptNumber int32 `protobuf:"varint,6,opt,name=attempt_number,json=attemptNumber,proto3" json:"attempt_number,omitempty"`
+	// HTTP status code of the last dispatch; 0 when no response was received
+	ResponseStatus int32 `protobuf:"varint,7,opt,name=response_status,json=responseStatus,proto3" json:"response_status,omitempty"`
+	// Error of a retried or failed attempt
+	Error string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	// Worker instance that processed the task
+	Worker        string `protobuf:"bytes,9,opt,name=worker,proto3" json:"worker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskEvent) Reset() {
+	*x = TaskEvent{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskEvent) ProtoMessage() {}
+
+func (x *TaskEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskEvent.ProtoReflect.Descriptor instead.
+func (*TaskEvent) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *TaskEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetQueue() string {
+	if x != nil {
+		return x.Queue
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetAttemptNumber() int32 {
+	if x != nil {
+		return x.AttemptNumber
+	}
+	return 0
+}
+
+func (x *TaskEvent) GetResponseStatus() int32 {
+	if x != nil {
+		return x.ResponseStatus
+	}
+	return 0
+}
+
+func (x *TaskEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+// ListTasksResponse lists the tasks matching a label selector
+type ListTasksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tasks         []*Task                `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksResponse) Reset() {
+	*x = ListTasksResponse{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksResponse) ProtoMessage() {}
+
+func (x *ListTasksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksResponse.ProtoReflect.Descriptor instead.
+func (*ListTasksResponse) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListTasksResponse) GetTasks() []*Task {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+// BatchTasksRequest selects the tasks of a queue by label
+type BatchTasksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tasks having all of these labels are selected
+	Labels        map[string]string `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchTasksRequest) Reset() {
+	*x = BatchTasksRequest{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTasksRequest) ProtoMessage() {}
+
+func (x *BatchTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTasksRequest.ProtoReflect.Descriptor instead.
+func (*BatchTasksRequest) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BatchTasksRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// BatchTasksResponse names the tasks a batch operation was applied to
+type BatchTasksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchTasksResponse) Reset() {
+	*x = BatchTasksResponse{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchTasksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTasksResponse) ProtoMessage() {}
+
+func (x *BatchTasksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTasksResponse.ProtoReflect.Descriptor instead.
+func (*BatchTasksResponse) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *BatchTasksResponse) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+// UpdateTaskRequest changes a pending, scheduled or retrying task
+type UpdateTaskRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// New RFC3339 schedule time; a pending task given a future time becomes scheduled
+	ScheduleTime string `protobuf:"bytes,1,opt,name=schedule_time,json=scheduleTime,proto3" json:"schedule_time,omitempty"`
+	// New body and headers; headers are merged into the task's, an empty value removes one. The URL cannot be changed
+	HttpRequest *HTTPRequest `protobuf:"bytes,2,opt,name=http_request,json=httpRequest,proto3" json:"http_request,omitempty"`
+	// Etag of the task the update is based on; the update is rejected if the task changed since (optional)
+	Etag string `protobuf:"bytes,3,opt,name=etag,proto3" json:"etag,omitempty"`
+	// Comma-separated fields to change: scheduleTime, httpRequest.headers, httpRequest.body; defaults to the fields set in the request
+	UpdateMask    string `protobuf:"bytes,4,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTaskRequest) Reset() {
+	*x = UpdateTaskRequest{}
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTaskRequest) ProtoMessage() {}
+
+func (x *UpdateTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_taskqueue_v1_taskqueue_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTaskRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTaskRequest) Descriptor() ([]byte, []int) {
+	return file_taskqueue_v1_taskqueue_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *UpdateTaskRequest) GetScheduleTime() string {
+	if x != nil {
+		return x.ScheduleTime
+	}
+	return ""
+}
+
+func (x *UpdateTaskRequest) GetHttpRequest() *HTTPRequest {
+	if x != nil {
+		return x.HttpRequest
+	}
+	return nil
+}
+
+func (x *UpdateTaskRequest) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *UpdateTaskRequest) GetUpdateMask() string {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return ""
+}
+
+var File_taskqueue_v1_taskqueue_proto protoreflect.FileDescriptor
+
+const file_taskqueue_v1_taskqueue_proto_rawDesc = "" +
+	"\n" +
+	"\x1ctaskqueue/v1/taskqueue.proto\x12\ftaskqueue.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb1\x01\n" +
+	"\vHTTPRequest\x12\x12\n" +
+	"\x04body\x18\x01 \x01(\tR\x04body\x12@\n" +
+	"\aheaders\x18\x02 \x03(\v2&.taskqueue.v1.HTTPRequest.HeadersEntryR\aheaders\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x84\x04\n" +
+	"\x04Task\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12D\n" +
+	"\fhttp_request\x18\x02 \x01(\v2\x19.taskqueue.v1.HTTPRequestB\x06\xbaH\x03\xc8\x01\x01R\vhttpRequest\x12#\n" +
+	"\rschedule_time\x18\x03 \x01(\tR\fscheduleTime\x12\x1f\n" +
+	"\vcreate_time\x18\x04 \x01(\tR\n" +
+	"createTime\x121\n" +
+	"\n" +
+	"on_success\x18\x05 \x01(\v2\x12.taskqueue.v1.TaskR\tonSuccess\x121\n" +
+	"\n" +
+	"on_failure\x18\x06 \x01(\v2\x12.taskqueue.v1.TaskR\tonFailure\x12\x14\n" +
+	"\x05state\x18\a \x01(\tR\x05state\x12\x1f\n" +
+	"\vretry_count\x18\b \x01(\x05R\n" +
+	"retryCount\x128\n" +
+	"\flast_attempt\x18\t \x01(\v2\x15.taskqueue.v1.AttemptR\vlastAttempt\x126\n" +
+	"\x06labels\x18\n" +
+	" \x03(\v2\x1e.taskqueue.v1.Task.LabelsEntryR\x06labels\x12\x12\n" +
+	"\x04etag\x18\v \x01(\tR\x04etag\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"C\n" +
+	"\x11CreateTaskRequest\x12.\n" +
+	"\x04task\x18\x01 \x01(\v2\x12.taskqueue.v1.TaskB\x06\xbaH\x03\xc8\x01\x01R\x04task\"n\n" +
+	"\x12CreateTaskResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\rschedule_time\x18\x02 \x01(\tR\fscheduleTime\x12\x1f\n" +
+	"\vcreate_time\x18\x03 \x01(\tR\n" +
+	"createTime\"/\n" +
+	"\x11DeleteTaskRequest\x12\x1a\n" +
+	"\x04name\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x04name\"\x14\n" +
+	"\x12DeleteTaskResponse\"\xfe\x04\n" +
+	"\vTaskPayload\x12\x12\n" +
+	"\x04body\x18\x01 \x01(\fR\x04body\x12@\n" +
+	"\aheaders\x18\x02 \x03(\v2&.taskqueue.v1.TaskPayload.HeadersEntryR\aheaders\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x124\n" +
+	"\x06sealed\x18\x04 \x01(\v2\x1c.taskqueue.v1.SealedEnvelopeR\x06sealed\x127\n" +
+	"\bbody_ref\x18\x05 \x01(\v2\x1c.taskqueue.v1.PayloadBodyRefR\abodyRef\x12#\n" +
+	"\rbody_encoding\x18\x06 \x01(\tR\fbodyEncoding\x12\x10\n" +
+	"\x03url\x18\a \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"on_success\x18\b \x01(\fR\tonSuccess\x12\x1d\n" +
+	"\n" +
+	"on_failure\x18\t \x01(\fR\tonFailure\x12\x1f\n" +
+	"\vworkflow_id\x18\n" +
+	" \x01(\tR\n" +
+	"workflowId\x12#\n" +
+	"\rworkflow_node\x18\v \x01(\tR\fworkflowNode\x12=\n" +
+	"\x06labels\x18\f \x03(\v2%.taskqueue.v1.TaskPayload.LabelsEntryR\x06labels\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"U\n" +
+	"\rErrorResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"h\n" +
+	"\x0eSealedEnvelope\x12\x15\n" +
+	"\x06key_id\x18\x01 \x01(\tR\x05keyId\x12\x1f\n" +
+	"\vwrapped_key\x18\x02 \x01(\fR\n" +
+	"wrappedKey\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\x03 \x01(\fR\n" +
+	"ciphertext\"N\n" +
+	"\x0ePayloadBodyRef\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sealed\x18\x03 \x01(\bR\x06sealed\"\xfd\x01\n" +
+	"\bSchedule\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\"\n" +
+	"\bschedule\x18\x02 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\bschedule\x12\x1b\n" +
+	"\ttime_zone\x18\x03 \x01(\tR\btimeZone\x12\x14\n" +
+	"\x05queue\x18\x04 \x01(\tR\x05queue\x12.\n" +
+	"\x04task\x18\x05 \x01(\v2\x12.taskqueue.v1.TaskB\x06\xbaH\x03\xc8\x01\x01R\x04task\x12\x14\n" +
+	"\x05state\x18\x06 \x01(\tR\x05state\x12\x1f\n" +
+	"\vcreate_time\x18\a \x01(\tR\n" +
+	"createTime\x12\x1f\n" +
+	"\vupdate_time\x18\b \x01(\tR\n" +
+	"updateTime\"S\n" +
+	"\x15CreateScheduleRequest\x12:\n" +
+	"\bschedule\x18\x01 \x01(\v2\x16.taskqueue.v1.ScheduleB\x06\xbaH\x03\xc8\x01\x01R\bschedule\"M\n" +
+	"\x15ListSchedulesResponse\x124\n" +
+	"\tschedules\x18\x01 \x03(\v2\x16.taskqueue.v1.ScheduleR\tschedules\"\x18\n" +
+	"\x16DeleteScheduleResponse\"\xbe\x01\n" +
+	"\bWorkflow\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05queue\x18\x02 \x01(\tR\x05queue\x120\n" +
+	"\x05nodes\x18\x03 \x03(\v2\x1a.taskqueue.v1.WorkflowNodeR\x05nodes\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x12\x1f\n" +
+	"\vcreate_time\x18\x05 \x01(\tR\n" +
+	"createTime\x12\x1f\n" +
+	"\vupdate_time\x18\x06 \x01(\tR\n" +
+	"updateTime\"\xba\x01\n" +
+	"\fWorkflowNode\x12\x16\n" +
+	"\x02id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x02id\x12\x1d\n" +
+	"\n" +
+	"depends_on\x18\x02 \x03(\tR\tdependsOn\x12.\n" +
+	"\x04task\x18\x03 \x01(\v2\x12.taskqueue.v1.TaskB\x06\xbaH\x03\xc8\x01\x01R\x04task\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x12\x17\n" +
+	"\atask_id\x18\x05 \x01(\tR\x06taskId\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"S\n" +
+	"\x15CreateWorkflowRequest\x12:\n" +
+	"\bworkflow\x18\x01 \x01(\v2\x16.taskqueue.v1.WorkflowB\x06\xbaH\x03\xc8\x01\x01R\bworkflow\"\x82\x04\n" +
+	"\aAttempt\x12#\n" +
+	"\rdispatch_time\x18\x01 \x01(\tR\fdispatchTime\x12#\n" +
+	"\rresponse_time\x18\x02 \x01(\tR\fresponseTime\x12'\n" +
+	"\x0fresponse_status\x18\x03 \x01(\x05R\x0eresponseStatus\x12U\n" +
+	"\x10response_headers\x18\x04 \x03(\v2*.taskqueue.v1.Attempt.ResponseHeadersEntryR\x0fresponseHeaders\x12#\n" +
+	"\rresponse_body\x18\x05 \x01(\fR\fresponseBody\x126\n" +
+	"\x17response_body_truncated\x18\x06 \x01(\bR\x15responseBodyTruncated\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12\x1f\n" +
+	"\vduration_ms\x18\b \x01(\x03R\n" +
+	"durationMs\x12\x16\n" +
+	"\x06reason\x18\t \x01(\tR\x06reason\x12\x16\n" +
+	"\x06worker\x18\n" +
+	" \x01(\tR\x06worker\x12%\n" +
+	"\x0eattempt_number\x18\v \x01(\x05R\rattemptNumber\x1aB\n" +
+	"\x14ResponseHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"I\n" +
+	"\x14ListAttemptsResponse\x121\n" +
+	"\battempts\x18\x01 \x03(\v2\x15.taskqueue.v1.AttemptR\battempts\"\x86\x02\n" +
+	"\fHistoryEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05queue\x18\x03 \x01(\tR\x05queue\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x12\n" +
+	"\x04time\x18\x05 \x01(\tR\x04time\x12'\n" +
+	"\x0fresponse_status\x18\x06 \x01(\x05R\x0eresponseStatus\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\x12%\n" +
+	"\x0eattempt_number\x18\t \x01(\x05R\rattemptNumber\x12\x16\n" +
+	"\x06worker\x18\n" +
+	" \x01(\tR\x06worker\"q\n" +
+	"\x13ListHistoryResponse\x122\n" +
+	"\x06events\x18\x01 \x03(\v2\x1a.taskqueue.v1.HistoryEventR\x06events\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xeb\x01\n" +
+	"\tTaskEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05queue\x18\x03 \x01(\tR\x05queue\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x12\n" +
+	"\x04time\x18\x05 \x01(\tR\x04time\x12%\n" +
+	"\x0eattempt_number\x18\x06 \x01(\x05R\rattemptNumber\x12'\n" +
+	"\x0fresponse_status\x18\a \x01(\x05R\x0eresponseStatus\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\x12\x16\n" +
+	"\x06worker\x18\t \x01(\tR\x06worker\"=\n" +
+	"\x11ListTasksResponse\x12(\n" +
+	"\x05tasks\x18\x01 \x03(\v2\x12.taskqueue.v1.TaskR\x05tasks\"\x9b\x01\n" +
+	"\x11BatchTasksRequest\x12K\n" +
+	"\x06labels\x18\x01 \x03(\v2+.taskqueue.v1.BatchTasksRequest.LabelsEntryB\x06\xbaH\x03\xc8\x01\x01R\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"*\n" +
+	"\x12BatchTasksResponse\x12\x14\n" +
+	"\x05names\x18\x01 \x03(\tR\x05names\"\xab\x01\n" +
+	"\x11UpdateTaskRequest\x12#\n" +
+	"\rschedule_time\x18\x01 \x01(\tR\fscheduleTime\x12<\n" +
+	"\fhttp_request\x18\x02 \x01(\v2\x19.taskqueue.v1.HTTPRequestR\vhttpRequest\x12\x12\n" +
+	"\x04etag\x18\x03 \x01(\tR\x04etag\x12\x1f\n" +
+	"\vupdate_mask\x18\x04 \x01(\tR\n" +
+	"updateMaskB\xc1\x01\n" +
 	"\x10com.taskqueue.v1B\x0eTaskqueueProtoP\x01ZLgithub.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1;taskqueuev1\xa2\x02\x03TXX\xaa\x02\fTaskqueue.V1\xca\x02\fTaskqueue\\V1\xe2\x02\x18Taskqueue\\V1\\GPBMetadata\xea\x02\rTaskqueue::V1b\x06proto3"
 
 var (
@@ -511,31 +2067,72 @@ func file_taskqueue_v1_taskqueue_proto_rawDescGZIP() []byte {
 	return file_taskqueue_v1_taskqueue_proto_rawDescData
 }
 
-var file_taskqueue_v1_taskqueue_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_taskqueue_v1_taskqueue_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
 var file_taskqueue_v1_taskqueue_proto_goTypes = []any{
-	(*HTTPRequest)(nil),           // 0: taskqueue.v1.HTTPRequest
-	(*Task)(nil),                  // 1: taskqueue.v1.Task
-	(*CreateTaskRequest)(nil),     // 2: taskqueue.v1.CreateTaskRequest
-	(*CreateTaskResponse)(nil),    // 3: taskqueue.v1.CreateTaskResponse
-	(*DeleteTaskRequest)(nil),     // 4: taskqueue.v1.DeleteTaskRequest
-	(*DeleteTaskResponse)(nil),    // 5: taskqueue.v1.DeleteTaskResponse
-	(*TaskPayload)(nil),           // 6: taskqueue.v1.TaskPayload
-	(*ErrorResponse)(nil),         // 7: taskqueue.v1.ErrorResponse
-	nil,                           // 8: taskqueue.v1.HTTPRequest.HeadersEntry
-	nil,                           // 9: taskqueue.v1.TaskPayload.HeadersEntry
-	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+	(*HTTPRequest)(nil),            // 0: taskqueue.v1.HTTPRequest
+	(*Task)(nil),                   // 1: taskqueue.v1.Task
+	(*CreateTaskRequest)(nil),      // 2: taskqueue.v1.CreateTaskRequest
+	(*CreateTaskResponse)(nil),     // 3: taskqueue.v1.CreateTaskResponse
+	(*DeleteTaskRequest)(nil),      // 4: taskqueue.v1.DeleteTaskRequest
+	(*DeleteTaskResponse)(nil),     // 5: taskqueue.v1.DeleteTaskResponse
+	(*TaskPayload)(nil),            // 6: taskqueue.v1.TaskPayload
+	(*ErrorResponse)(nil),          // 7: taskqueue.v1.ErrorResponse
+	(*SealedEnvelope)(nil),         // 8: taskqueue.v1.SealedEnvelope
+	(*PayloadBodyRef)(nil),         // 9: taskqueue.v1.PayloadBodyRef
+	(*Schedule)(nil),               // 10: taskqueue.v1.Schedule
+	(*CreateScheduleRequest)(nil),  // 11: taskqueue.v1.CreateScheduleRequest
+	(*ListSchedulesResponse)(nil),  // 12: taskqueue.v1.ListSchedulesResponse
+	(*DeleteScheduleResponse)(nil), // 13: taskqueue.v1.DeleteScheduleResponse
+	(*Workflow)(nil),               // 14: taskqueue.v1.Workflow
+	(*WorkflowNode)(nil),           // 15: taskqueue.v1.WorkflowNode
+	(*CreateWorkflowRequest)(nil),  // 16: taskqueue.v1.CreateWorkflowRequest
+	(*Attempt)(nil),                // 17: taskqueue.v1.Attempt
+	(*ListAttemptsResponse)(nil),   // 18: taskqueue.v1.ListAttemptsResponse
+	(*HistoryEvent)(nil),           // 19: taskqueue.v1.HistoryEvent
+	(*ListHistoryResponse)(nil),    // 20: taskqueue.v1.ListHistoryResponse
+	(*TaskEvent)(nil),              // 21: taskqueue.v1.TaskEvent
+	(*ListTasksResponse)(nil),      // 22: taskqueue.v1.ListTasksResponse
+	(*BatchTasksRequest)(nil),      // 23: taskqueue.v1.BatchTasksRequest
+	(*BatchTasksResponse)(nil),     // 24: taskqueue.v1.BatchTasksResponse
+	(*UpdateTaskRequest)(nil),      // 25: taskqueue.v1.UpdateTaskRequest
+	nil,                            // 26: taskqueue.v1.HTTPRequest.HeadersEntry
+	nil,                            // 27: taskqueue.v1.Task.LabelsEntry
+	nil,                            // 28: taskqueue.v1.TaskPayload.HeadersEntry
+	nil,                            // 29: taskqueue.v1.TaskPayload.LabelsEntry
+	nil,                            // 30: taskqueue.v1.Attempt.ResponseHeadersEntry
+	nil,                            // 31: taskqueue.v1.BatchTasksRequest.LabelsEntry
+	(*timestamppb.Timestamp)(nil),  // 32: google.protobuf.Timestamp
 }
 var file_taskqueue_v1_taskqueue_proto_depIdxs = []int32{
-	8,  // 0: taskqueue.v1.HTTPRequest.headers:type_name -> taskqueue.v1.HTTPRequest.HeadersEntry
+	26, // 0: taskqueue.v1.HTTPRequest.headers:type_name -> taskqueue.v1.HTTPRequest.HeadersEntry
 	0,  // 1: taskqueue.v1.Task.http_request:type_name -> taskqueue.v1.HTTPRequest
-	1,  // 2: taskqueue.v1.CreateTaskRequest.task:type_name -> taskqueue.v1.Task
-	9,  // 3: taskqueue.v1.TaskPayload.headers:type_name -> taskqueue.v1.TaskPayload.HeadersEntry
-	10, // 4: taskqueue.v1.TaskPayload.created_at:type_name -> google.protobuf.Timestamp
-	5,  // [5:5] is the sub-list for method output_type
-	5,  // [5:5] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	1,  // 2: taskqueue.v1.Task.on_success:type_name -> taskqueue.v1.Task
+	1,  // 3: taskqueue.v1.Task.on_failure:type_name -> taskqueue.v1.Task
+	17, // 4: taskqueue.v1.Task.last_attempt:type_name -> taskqueue.v1.Attempt
+	27, // 5: taskqueue.v1.Task.labels:type_name -> taskqueue.v1.Task.LabelsEntry
+	1,  // 6: taskqueue.v1.CreateTaskRequest.task:type_name -> taskqueue.v1.Task
+	28, // 7: taskqueue.v1.TaskPayload.headers:type_name -> taskqueue.v1.TaskPayload.HeadersEntry
+	32, // 8: taskqueue.v1.TaskPayload.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 9: taskqueue.v1.TaskPayload.sealed:type_name -> taskqueue.v1.SealedEnvelope
+	9,  // 10: taskqueue.v1.TaskPayload.body_ref:type_name -> taskqueue.v1.PayloadBodyRef
+	29, // 11: taskqueue.v1.TaskPayload.labels:type_name -> taskqueue.v1.TaskPayload.LabelsEntry
+	1,  // 12: taskqueue.v1.Schedule.task:type_name -> taskqueue.v1.Task
+	10, // 13: taskqueue.v1.CreateScheduleRequest.schedule:type_name -> taskqueue.v1.Schedule
+	10, // 14: taskqueue.v1.ListSchedulesResponse.schedules:type_name -> taskqueue.v1.Schedule
+	15, // 15: taskqueue.v1.Workflow.nodes:type_name -> taskqueue.v1.WorkflowNode
+	1,  // 16: taskqueue.v1.WorkflowNode.task:type_name -> taskqueue.v1.Task
+	14, // 17: taskqueue.v1.CreateWorkflowRequest.workflow:type_name -> taskqueue.v1.Workflow
+	30, // 18: taskqueue.v1.Attempt.response_headers:type_name -> taskqueue.v1.Attempt.ResponseHeadersEntry
+	17, // 19: taskqueue.v1.ListAttemptsResponse.attempts:type_name -> taskqueue.v1.Attempt
+	19, // 20: taskqueue.v1.ListHistoryResponse.events:type_name -> taskqueue.v1.HistoryEvent
+	1,  // 21: taskqueue.v1.ListTasksResponse.tasks:type_name -> taskqueue.v1.Task
+	31, // 22: taskqueue.v1.BatchTasksRequest.labels:type_name -> taskqueue.v1.BatchTasksRequest.LabelsEntry
+	0,  // 23: taskqueue.v1.UpdateTaskRequest.http_request:type_name -> taskqueue.v1.HTTPRequest
+	24, // [24:24] is the sub-list for method output_type
+	24, // [24:24] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_taskqueue_v1_taskqueue_proto_init() }
@@ -549,7 +2146,7 @@ func file_taskqueue_v1_taskqueue_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_taskqueue_v1_taskqueue_proto_rawDesc), len(file_taskqueue_v1_taskqueue_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   10,
+			NumMessages:   32,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -36,10 +36,19 @@ type QueueClient interface {
 	Ping() error
 }
 
+// CheckFunc reports the health of a single dependency.
+type CheckFunc func(ctx context.Context) error
+
+type namedCheck struct {
+	name string
+	fn   CheckFunc
+}
+
 // Checker performs health checks on service dependencies.
 type Checker struct {
 	client  QueueClient
 	version string
+	checks  []namedCheck
 }
 
 // NewChecker creates a new health checker with the given dependencies.
@@ -50,8 +59,14 @@ func NewChecker(client QueueClient, version string) *Checker {
 	}
 }
 
+// AddCheck registers an additional dependency check reported under name.
+// It must be called before the checker starts serving requests.
+func (c *Checker) AddCheck(name string, fn CheckFunc) {
+	c.checks = append(c.checks, namedCheck{name: name, fn: fn})
+}
+
 // Check performs health checks on all dependencies and returns the overall status.
-func (c *Checker) Check(_ context.Context) *HealthStatus {
+func (c *Checker) Check(ctx context.Context) *HealthStatus {
 	status := &HealthStatus{
 		Status:  StatusHealthy,
 		Version: c.version,
@@ -75,6 +90,22 @@ func (c *Checker) Check(_ context.Context) *HealthStatus {
 		}
 	}
 
+	for _, check := range c.checks {
+		start := time.Now()
+		if err := check.fn(ctx); err != nil {
+			status.Status = StatusUnhealthy
+			status.Checks[check.name] = CheckResult{
+				Status: StatusUnhealthy,
+				Error:  err.Error(),
+			}
+		} else {
+			status.Checks[check.name] = CheckResult{
+				Status:    StatusHealthy,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+		}
+	}
+
 	return status
 }
 
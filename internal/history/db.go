@@ -0,0 +1,30 @@
+package history
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/KasumiMercury/primind-tasks/internal/config"
+	"github.com/KasumiMercury/primind-tasks/internal/database"
+)
+
+// OpenDB connects to the history database configured by HISTORY_DB_DRIVER
+// and HISTORY_DB_DSN and creates the task_events table if needed. It returns
+// nil when history is disabled.
+func OpenDB(cfg *config.Config) (*gorm.DB, error) {
+	if cfg.HistoryDBDriver == "" {
+		return nil, nil
+	}
+
+	db, err := database.Open(cfg.HistoryDBDriver, cfg.HistoryDBDSN)
+	if err != nil {
+		return nil, fmt.Errorf("open history database: %w", err)
+	}
+
+	if err := db.AutoMigrate(&Event{}); err != nil {
+		return nil, fmt.Errorf("migrate history database: %w", err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,32 @@
+// Package history keeps task lifecycle events in a SQL database for
+// long-term inspection beyond Redis retention.
+package history
+
+import "time"
+
+// Event types.
+const (
+	EventCreated    = "CREATED"
+	EventDispatched = "DISPATCHED"
+	EventSucceeded  = "SUCCEEDED"
+	EventFailed     = "FAILED"
+	EventDeleted    = "DELETED"
+)
+
+// Event is a row of the task_events table.
+type Event struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement"`
+	TaskID         string    `gorm:"size:255;not null;index:idx_task_events_task"`
+	Queue          string    `gorm:"size:255;not null;index:idx_task_events_queue_time,priority:1"`
+	Type           string    `gorm:"size:16;not null"`
+	OccurredAt     time.Time `gorm:"not null;index:idx_task_events_queue_time,priority:2;index:idx_task_events_time"`
+	ResponseStatus int
+	Reason         string `gorm:"size:64"`
+	Error          string `gorm:"size:1024"`
+	AttemptNumber  int
+	Worker         string `gorm:"size:255"`
+}
+
+func (Event) TableName() string {
+	return "task_events"
+}
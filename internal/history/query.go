@@ -0,0 +1,63 @@
+package history
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Filter selects history events. Zero fields match everything.
+type Filter struct {
+	TaskID string
+	Queue  string
+	Type   string
+	Since  time.Time
+	Until  time.Time
+	// BeforeID continues a listing below the given event ID
+	BeforeID int64
+	Limit    int
+}
+
+// Store queries the history database.
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Search returns events matching f, newest first.
+func (s *Store) Search(ctx context.Context, f Filter) ([]Event, error) {
+	q := s.db.WithContext(ctx).Model(&Event{})
+
+	if f.TaskID != "" {
+		q = q.Where("task_id = ?", f.TaskID)
+	}
+	if f.Queue != "" {
+		q = q.Where("queue = ?", f.Queue)
+	}
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+	if !f.Since.IsZero() {
+		q = q.Where("occurred_at >= ?", f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		q = q.Where("occurred_at < ?", f.Until.UTC())
+	}
+	if f.BeforeID > 0 {
+		q = q.Where("id < ?", f.BeforeID)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+
+	var events []Event
+	if err := q.Order("id DESC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,135 @@
+package history
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/KasumiMercury/primind-tasks/internal/errmsg"
+)
+
+const (
+	// batchSize is the number of events written per insert
+	batchSize = 100
+	// flushInterval bounds how long an event waits for a batch to fill
+	flushInterval = time.Second
+	// writeTimeout bounds a single batch insert
+	writeTimeout = 10 * time.Second
+)
+
+// Sink writes events to the database in the background, so recording never
+// blocks task processing. Events are dropped when the buffer is full. A nil
+// Sink discards all events.
+type Sink struct {
+	db     *gorm.DB
+	events chan Event
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewSink starts a sink buffering up to bufferSize events.
+func NewSink(db *gorm.DB, bufferSize int) *Sink {
+	s := &Sink{
+		db:     db,
+		events: make(chan Event, bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+// Record queues ev for writing.
+func (s *Sink) Record(ctx context.Context, ev Event) {
+	if s == nil {
+		return
+	}
+
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now()
+	}
+	ev.OccurredAt = ev.OccurredAt.UTC()
+	ev.Error = errmsg.Truncate(ev.Error)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.events <- ev:
+	default:
+		slog.WarnContext(ctx, "history buffer full, dropping event",
+			slog.String("event", "history.drop"),
+			slog.String("task_id", ev.TaskID),
+			slog.String("history.type", ev.Type),
+		)
+	}
+}
+
+// Close writes the buffered events and stops the sink.
+func (s *Sink) Close() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mu.Unlock()
+
+	<-s.done
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, batchSize)
+	for {
+		select {
+		case ev, ok := <-s.events:
+			if !ok {
+				s.write(batch)
+				return
+			}
+			batch = append(batch, ev)
+			if len(batch) >= batchSize {
+				s.write(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.write(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (s *Sink) write(batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	if err := s.db.WithContext(ctx).Create(&batch).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to write history events",
+			slog.String("event", "history.write.fail"),
+			slog.Int("count", len(batch)),
+			slog.String("error", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,118 @@
+// Package labels indexes task labels in Redis so that tasks can be found
+// and operated on by label.
+package labels
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix prefixes the Redis set holding the IDs of the tasks of a queue
+// with a given label.
+const keyPrefix = "primind:labels:"
+
+// MaxLabels bounds the labels of a task.
+const MaxLabels = 16
+
+var (
+	keyPattern   = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,63}$`)
+	valuePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{0,63}$`)
+)
+
+// Validate checks the number, keys and values of labels.
+func Validate(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("%d labels given, the limit is %d", len(labels), MaxLabels)
+	}
+	for k, v := range labels {
+		if !keyPattern.MatchString(k) {
+			return fmt.Errorf("invalid label key %q: use 1 to 63 letters, digits, '_', '.' or '-'", k)
+		}
+		if !valuePattern.MatchString(v) {
+			return fmt.Errorf("invalid value %q of label %q: use up to 63 letters, digits, '_', '.' or '-'", v, k)
+		}
+	}
+
+	return nil
+}
+
+// ParseSelector parses "key:value" selectors, as in ?label=user_id:123, into
+// a label map matched by tasks having all of them.
+func ParseSelector(selectors []string) (map[string]string, error) {
+	selector := make(map[string]string, len(selectors))
+	for _, s := range selectors {
+		k, v, ok := strings.Cut(s, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid label selector %q: use key:value", s)
+		}
+		selector[k] = v
+	}
+
+	if err := Validate(selector); err != nil {
+		return nil, err
+	}
+
+	return selector, nil
+}
+
+// Index keeps, for every label of a queue, the set of task IDs having it.
+// Entries of tasks that no longer exist are left behind and removed by the
+// caller when found through Find. A nil Index indexes nothing.
+type Index struct {
+	rdb redis.UniversalClient
+}
+
+func NewIndex(rdb redis.UniversalClient) *Index {
+	return &Index{rdb: rdb}
+}
+
+func labelKey(queueName, key, value string) string {
+	return fmt.Sprintf("%s{%s}:%s=%s", keyPrefix, queueName, key, value)
+}
+
+// Add indexes a task under each of its labels.
+func (i *Index) Add(ctx context.Context, queueName, taskID string, labels map[string]string) error {
+	if i == nil || len(labels) == 0 {
+		return nil
+	}
+
+	_, err := i.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for k, v := range labels {
+			pipe.SAdd(ctx, labelKey(queueName, k, v), taskID)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Remove drops a task from the index of each of labels.
+func (i *Index) Remove(ctx context.Context, queueName, taskID string, labels map[string]string) error {
+	if i == nil || len(labels) == 0 {
+		return nil
+	}
+
+	_, err := i.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for k, v := range labels {
+			pipe.SRem(ctx, labelKey(queueName, k, v), taskID)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Find returns the IDs of the tasks of a queue having all labels of
+// selector.
+func (i *Index) Find(ctx context.Context, queueName string, selector map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(selector))
+	for k, v := range selector {
+		keys = append(keys, labelKey(queueName, k, v))
+	}
+
+	return i.rdb.SInter(ctx, keys...).Result()
+}
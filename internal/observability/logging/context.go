@@ -1,14 +1,25 @@
 package logging
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 type contextKey string
 
 const (
 	requestIDKey contextKey = "x-request-id"
 	moduleKey    contextKey = "module"
+	principalKey contextKey = "principal"
 )
 
+// principalSlot lets a principal resolved by an inner handler reach loggers
+// holding an outer context, such as the HTTP access log.
+type principalSlot struct {
+	mu sync.RWMutex
+	id string
+}
+
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, requestIDKey, requestID)
 }
@@ -34,3 +45,34 @@ func ModuleFromContext(ctx context.Context) Module {
 
 	return v
 }
+
+// WithPrincipalSlot prepares ctx so that a principal set further down the
+// handler chain is also logged with ctx.
+func WithPrincipalSlot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, principalKey, &principalSlot{})
+}
+
+// WithPrincipal records the authenticated principal ID for logging.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	if slot, ok := ctx.Value(principalKey).(*principalSlot); ok {
+		slot.mu.Lock()
+		slot.id = principal
+		slot.mu.Unlock()
+
+		return ctx
+	}
+
+	return context.WithValue(ctx, principalKey, &principalSlot{id: principal})
+}
+
+func PrincipalFromContext(ctx context.Context) string {
+	slot, ok := ctx.Value(principalKey).(*principalSlot)
+	if !ok {
+		return ""
+	}
+
+	slot.mu.RLock()
+	defer slot.mu.RUnlock()
+
+	return slot.id
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"slices"
+	"strings"
 
 	"go.opentelemetry.io/otel/trace"
 )
@@ -21,6 +23,22 @@ type HandlerConfig struct {
 	Environment   Environment
 	GCPProject    string // empty for non-GCP environments
 	DefaultModule Module
+	// RedactKeys are attribute keys (case-insensitive) whose values are
+	// replaced before output, in addition to DefaultRedactKeys; keys of
+	// map[string]string values such as headers are matched too.
+	RedactKeys []string
+}
+
+const redactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are always redacted.
+var DefaultRedactKeys = []string{
+	"authorization",
+	"proxy-authorization",
+	"cookie",
+	"set-cookie",
+	"x-api-key",
+	"response.body",
 }
 
 func NewHandler(w io.Writer, opts *slog.HandlerOptions, cfg HandlerConfig) *Handler {
@@ -28,8 +46,17 @@ func NewHandler(w io.Writer, opts *slog.HandlerOptions, cfg HandlerConfig) *Hand
 		opts = &slog.HandlerOptions{Level: slog.LevelDebug}
 	}
 
+	redactSet := make(map[string]struct{}, len(DefaultRedactKeys)+len(cfg.RedactKeys))
+	for _, k := range append(slices.Clone(DefaultRedactKeys), cfg.RedactKeys...) {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			redactSet[k] = struct{}{}
+		}
+	}
+
 	originalReplaceAttr := opts.ReplaceAttr
 	opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		a = redactAttr(a, redactSet)
+
 		switch a.Key {
 		case slog.TimeKey:
 			a.Key = "timestamp"
@@ -59,6 +86,7 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	hasEvent := false
 	hasModule := false
 	hasRequestID := false
+	hasPrincipal := false
 
 	r.Attrs(func(a slog.Attr) bool {
 		switch a.Key {
@@ -68,6 +96,8 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 			hasModule = true
 		case "x-request-id":
 			hasRequestID = true
+		case "principal":
+			hasPrincipal = true
 		}
 
 		return true
@@ -93,6 +123,12 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 		r.AddAttrs(slog.String("x-request-id", requestID))
 	}
 
+	if !hasPrincipal {
+		if principal := PrincipalFromContext(ctx); principal != "" {
+			r.AddAttrs(slog.String("principal", principal))
+		}
+	}
+
 	if !hasModule {
 		module := ModuleFromContext(ctx)
 		if module == "" {
@@ -152,3 +188,32 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 		defaultModule: h.defaultModule,
 	}
 }
+
+func redactAttr(a slog.Attr, redactSet map[string]struct{}) slog.Attr {
+	if len(redactSet) == 0 {
+		return a
+	}
+
+	if _, ok := redactSet[strings.ToLower(a.Key)]; ok {
+		return slog.String(a.Key, redactedValue)
+	}
+
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+
+	values, ok := a.Value.Any().(map[string]string)
+	if !ok {
+		return a
+	}
+
+	redacted := make(map[string]string, len(values))
+	for k, v := range values {
+		if _, ok := redactSet[strings.ToLower(k)]; ok {
+			v = redactedValue
+		}
+		redacted[k] = v
+	}
+
+	return slog.Any(a.Key, redacted)
+}
@@ -17,6 +17,8 @@ const (
 type HTTPMetrics struct {
 	requestCounter  metric.Int64Counter
 	requestDuration metric.Float64Histogram
+	requestSize     metric.Int64Histogram
+	responseSize    metric.Int64Histogram
 }
 
 func NewHTTPMetrics() (*HTTPMetrics, error) {
@@ -43,19 +45,49 @@ func NewHTTPMetrics() (*HTTPMetrics, error) {
 		return nil, err
 	}
 
+	sizeBuckets := metric.WithExplicitBucketBoundaries(
+		100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000,
+	)
+
+	requestSize, err := meter.Int64Histogram(
+		"http_request_size_bytes",
+		metric.WithDescription("HTTP request body size in bytes"),
+		metric.WithUnit("By"),
+		sizeBuckets,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	responseSize, err := meter.Int64Histogram(
+		"http_response_size_bytes",
+		metric.WithDescription("HTTP response body size in bytes"),
+		metric.WithUnit("By"),
+		sizeBuckets,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &HTTPMetrics{
 		requestCounter:  requestCounter,
 		requestDuration: requestDuration,
+		requestSize:     requestSize,
+		responseSize:    responseSize,
 	}, nil
 }
 
-func (m *HTTPMetrics) Record(ctx context.Context, method, path string, statusCode int, duration time.Duration) {
-	attrs := []attribute.KeyValue{
+// Record records a finished request. path must be a route pattern rather
+// than the raw URL path to keep attribute cardinality bounded.
+func (m *HTTPMetrics) Record(ctx context.Context, method, path string, statusCode int, duration time.Duration, requestSize, responseSize int64) {
+	attrs := metric.WithAttributes(
 		attribute.String("method", method),
 		attribute.String("path", path),
 		attribute.String("status_code", strconv.Itoa(statusCode)),
-	}
+	)
 
-	m.requestCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
-	m.requestDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attrs...))
+	m.requestCounter.Add(ctx, 1, attrs)
+	m.requestDuration.Record(ctx, duration.Seconds(), attrs)
+	m.requestSize.Record(ctx, requestSize, attrs)
+	m.responseSize.Record(ctx, responseSize, attrs)
 }
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"context"
+	"net/http"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
@@ -16,6 +17,9 @@ type Config struct {
 
 type Provider struct {
 	mp *sdkmetric.MeterProvider
+	// handler serves the Prometheus scrape endpoint; nil unless the
+	// Prometheus exporter is selected
+	handler http.Handler
 }
 
 func (p *Provider) Shutdown(ctx context.Context) error {
@@ -33,3 +37,9 @@ func (p *Provider) MeterProvider() metric.MeterProvider {
 func (p *Provider) SetGlobalProvider() {
 	otel.SetMeterProvider(p.mp)
 }
+
+// Handler returns the Prometheus scrape handler, or nil when metrics are
+// pushed over OTLP or not exported at all.
+func (p *Provider) Handler() http.Handler {
+	return p.handler
+}
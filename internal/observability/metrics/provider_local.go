@@ -8,13 +8,24 @@ import (
 	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.38.0"
 )
 
+const exporterPrometheus = "prometheus"
+
 func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
+	// Prometheus pull mode does not depend on an OTLP collector, so it takes
+	// precedence over OTEL_EXPORTER_DISABLED
+	if os.Getenv("OTEL_METRICS_EXPORTER") == exporterPrometheus {
+		return newPrometheusProvider(cfg)
+	}
+
 	if os.Getenv("OTEL_EXPORTER_DISABLED") == "true" {
 		return newNoopProvider(cfg), nil
 	}
@@ -42,34 +53,50 @@ func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
 		return nil, err
 	}
 
-	res := resource.NewWithAttributes(
-		semconv.SchemaURL,
-		semconv.ServiceName(cfg.ServiceName),
-		semconv.ServiceVersion(cfg.ServiceVersion),
-		semconv.DeploymentEnvironmentName(cfg.Environment),
-	)
-
 	mp := sdkmetric.NewMeterProvider(
 		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter,
 			sdkmetric.WithInterval(15*time.Second))),
-		sdkmetric.WithResource(res),
+		sdkmetric.WithResource(newResource(cfg)),
 	)
 
 	return &Provider{mp: mp}, nil
 }
 
-func newNoopProvider(cfg Config) *Provider {
-	res := resource.NewWithAttributes(
-		semconv.SchemaURL,
-		semconv.ServiceName(cfg.ServiceName),
-		semconv.ServiceVersion(cfg.ServiceVersion),
-		semconv.DeploymentEnvironmentName(cfg.Environment),
+func newPrometheusProvider(cfg Config) (*Provider, error) {
+	// Use a dedicated registry so only OTel instruments are exposed
+	registry := prometheus.NewRegistry()
+
+	exporter, err := otelprom.New(otelprom.WithRegisterer(registry))
+	if err != nil {
+		return nil, err
+	}
+
+	// Resource attributes are exposed through the target_info metric
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(exporter),
+		sdkmetric.WithResource(newResource(cfg)),
 	)
 
+	return &Provider{
+		mp:      mp,
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}, nil
+}
+
+func newNoopProvider(cfg Config) *Provider {
 	// MeterProvider without any reader does not export metrics
 	mp := sdkmetric.NewMeterProvider(
-		sdkmetric.WithResource(res),
+		sdkmetric.WithResource(newResource(cfg)),
 	)
 
 	return &Provider{mp: mp}
 }
+
+func newResource(cfg Config) *resource.Resource {
+	return resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+		semconv.DeploymentEnvironmentName(cfg.Environment),
+	)
+}
@@ -0,0 +1,212 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	queueMeterName = "asynq.queue"
+
+	defaultQueueCollectInterval = 15 * time.Second
+)
+
+// QueueInspector is the subset of asynq.Inspector used to collect queue statistics.
+type QueueInspector interface {
+	Queues() ([]string, error)
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+}
+
+// QueueCollector periodically snapshots queue statistics from Redis and
+// publishes them as observable gauges.
+type QueueCollector struct {
+	inspector    QueueInspector
+	interval     time.Duration
+	registration metric.Registration
+
+	mu       sync.RWMutex
+	snapshot []*asynq.QueueInfo
+}
+
+// NewQueueCollector registers the queue gauges on the global meter provider.
+// Call Run to start polling Redis.
+func NewQueueCollector(inspector QueueInspector, interval time.Duration) (*QueueCollector, error) {
+	if interval <= 0 {
+		interval = defaultQueueCollectInterval
+	}
+
+	c := &QueueCollector{
+		inspector: inspector,
+		interval:  interval,
+	}
+
+	meter := otel.Meter(queueMeterName)
+
+	tasks, err := meter.Int64ObservableGauge(
+		"asynq_queue_tasks",
+		metric.WithDescription("Number of tasks in the queue by state"),
+		metric.WithUnit("{task}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	latency, err := meter.Float64ObservableGauge(
+		"asynq_queue_latency_seconds",
+		metric.WithDescription("Age of the oldest pending task in the queue"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	processed, err := meter.Int64ObservableGauge(
+		"asynq_queue_processed_daily",
+		metric.WithDescription("Number of tasks processed today (resets daily)"),
+		metric.WithUnit("{task}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	failed, err := meter.Int64ObservableGauge(
+		"asynq_queue_failed_daily",
+		metric.WithDescription("Number of tasks failed today (resets daily)"),
+		metric.WithUnit("{task}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	memory, err := meter.Int64ObservableGauge(
+		"asynq_queue_memory_usage_bytes",
+		metric.WithDescription("Approximate Redis memory used by the queue"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	paused, err := meter.Int64ObservableGauge(
+		"asynq_queue_paused",
+		metric.WithDescription("Whether the queue is paused (1) or not (0)"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	registration, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, info := range c.Snapshot() {
+			queueAttr := attribute.String("queue", info.Queue)
+			states := []struct {
+				name  string
+				count int
+			}{
+				{"pending", info.Pending},
+				{"active", info.Active},
+				{"scheduled", info.Scheduled},
+				{"retry", info.Retry},
+				{"archived", info.Archived},
+				{"completed", info.Completed},
+				{"aggregating", info.Aggregating},
+			}
+			for _, s := range states {
+				o.ObserveInt64(tasks, int64(s.count),
+					metric.WithAttributes(queueAttr, attribute.String("state", s.name)))
+			}
+
+			o.ObserveFloat64(latency, info.Latency.Seconds(), metric.WithAttributes(queueAttr))
+			o.ObserveInt64(processed, int64(info.Processed), metric.WithAttributes(queueAttr))
+			o.ObserveInt64(failed, int64(info.Failed), metric.WithAttributes(queueAttr))
+			o.ObserveInt64(memory, info.MemoryUsage, metric.WithAttributes(queueAttr))
+
+			pausedValue := int64(0)
+			if info.Paused {
+				pausedValue = 1
+			}
+			o.ObserveInt64(paused, pausedValue, metric.WithAttributes(queueAttr))
+		}
+
+		return nil
+	}, tasks, latency, processed, failed, memory, paused)
+	if err != nil {
+		return nil, err
+	}
+
+	c.registration = registration
+
+	return c, nil
+}
+
+// Run polls queue statistics until ctx is cancelled.
+func (c *QueueCollector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.collect(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.collect(ctx)
+		}
+	}
+}
+
+// Snapshot returns the queue statistics from the most recent poll.
+func (c *QueueCollector) Snapshot() []*asynq.QueueInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.snapshot
+}
+
+// Shutdown unregisters the gauge callback.
+func (c *QueueCollector) Shutdown() error {
+	if c.registration == nil {
+		return nil
+	}
+
+	return c.registration.Unregister()
+}
+
+func (c *QueueCollector) collect(ctx context.Context) {
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		slog.WarnContext(ctx, "failed to list queues",
+			slog.String("event", "metrics.queue.collect.fail"),
+			slog.String("error", err.Error()),
+		)
+
+		return
+	}
+
+	snapshot := make([]*asynq.QueueInfo, 0, len(queues))
+	for _, q := range queues {
+		info, err := c.inspector.GetQueueInfo(q)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to get queue info",
+				slog.String("event", "metrics.queue.collect.fail"),
+				slog.String("queue", q),
+				slog.String("error", err.Error()),
+			)
+
+			continue
+		}
+
+		snapshot = append(snapshot, info)
+	}
+
+	c.mu.Lock()
+	c.snapshot = snapshot
+	c.mu.Unlock()
+}
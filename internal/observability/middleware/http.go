@@ -2,16 +2,22 @@ package middleware
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
+	"github.com/KasumiMercury/primind-tasks/internal/observability/metrics"
 	"github.com/KasumiMercury/primind-tasks/internal/observability/tracing"
 	"go.opentelemetry.io/otel"
 )
 
+// unmatchedRoute is recorded when no route pattern is available, so raw
+// paths never end up as metric attributes
+const unmatchedRoute = "unmatched"
+
 type HTTPConfig struct {
 	// SkipPaths are paths that skip observability
 	SkipPaths []string
@@ -22,14 +28,22 @@ type HTTPConfig struct {
 	// JobNameResolver returns a job name for worker-style logging
 	JobNameResolver func(*http.Request) string
 	TracerName      string
-	// SpanNameResolver returns a span name for the request
+	// SpanNameResolver returns a span name for the request. It is called again
+	// after the request is served, so resolvers relying on routing results
+	// (e.g. chi route patterns) can rename the span.
 	SpanNameResolver func(*http.Request) string
+	// Metrics records request count, duration and sizes when set
+	Metrics *metrics.HTTPMetrics
+	// RouteResolver returns the route pattern of a served request, used as the
+	// metrics path attribute
+	RouteResolver func(*http.Request) string
 }
 
 type responseWriter struct {
 	http.ResponseWriter
 	status      int
 	wroteHeader bool
+	written     int64
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -47,7 +61,37 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 		rw.WriteHeader(http.StatusOK)
 	}
 
-	return rw.ResponseWriter.Write(b)
+	n, err := rw.ResponseWriter.Write(b)
+	rw.written += int64(n)
+
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if !rw.wroteHeader {
+			rw.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// countingBody counts request body bytes for requests without Content-Length.
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+
+	return n, err
 }
 
 func HTTP(next http.Handler, cfg HTTPConfig) http.Handler {
@@ -64,16 +108,28 @@ func HTTP(next http.Handler, cfg HTTPConfig) http.Handler {
 			return
 		}
 
+		start := time.Now()
+
+		var body *countingBody
+		if cfg.Metrics != nil && r.Body != nil && r.Body != http.NoBody {
+			body = &countingBody{ReadCloser: r.Body}
+			r.Body = body
+		}
+
+		// Connect and gRPC requests only contribute to metrics
 		if isConnectRequest(r) {
-			next.ServeHTTP(w, r)
+			wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(wrapped, r)
+
+			recordMetrics(r, cfg, wrapped, body, time.Since(start))
 
 			return
 		}
 
-		start := time.Now()
-
 		requestID := logging.ValidateAndExtractRequestID(r.Header.Get("x-request-id"))
 		ctx := logging.WithRequestID(r.Context(), requestID)
+		ctx = logging.WithPrincipalSlot(ctx)
 		module := cfg.Module
 		if cfg.ModuleResolver != nil {
 			module = cfg.ModuleResolver(r)
@@ -129,10 +185,19 @@ func HTTP(next http.Handler, cfg HTTPConfig) http.Handler {
 
 		wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
 
-		next.ServeHTTP(wrapped, r.WithContext(ctx))
+		r = r.WithContext(ctx)
+		next.ServeHTTP(wrapped, r)
 
 		duration := time.Since(start)
 
+		if cfg.SpanNameResolver != nil {
+			if resolved := cfg.SpanNameResolver(r); resolved != "" && resolved != spanName {
+				span.SetName(resolved)
+			}
+		}
+
+		recordMetrics(r, cfg, wrapped, body, duration)
+
 		finishAttrs := []slog.Attr{
 			slog.String("event", finishEvent),
 			slog.String("method", r.Method),
@@ -151,6 +216,33 @@ func HTTP(next http.Handler, cfg HTTPConfig) http.Handler {
 	})
 }
 
+func recordMetrics(r *http.Request, cfg HTTPConfig, rw *responseWriter, body *countingBody, duration time.Duration) {
+	if cfg.Metrics == nil {
+		return
+	}
+
+	route := ""
+	if cfg.RouteResolver != nil {
+		route = cfg.RouteResolver(r)
+	}
+	if route == "" {
+		route = r.Pattern
+	}
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	requestSize := r.ContentLength
+	if body != nil && body.read > requestSize {
+		requestSize = body.read
+	}
+	if requestSize < 0 {
+		requestSize = 0
+	}
+
+	cfg.Metrics.Record(r.Context(), r.Method, route, rw.status, duration, requestSize, rw.written)
+}
+
 func isConnectRequest(r *http.Request) bool {
 	if r.Header.Get("Connect-Protocol-Version") != "" {
 		return true
@@ -4,7 +4,9 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
 	"github.com/KasumiMercury/primind-tasks/internal/observability/metrics"
@@ -63,6 +65,7 @@ func Init(ctx context.Context, cfg Config) (*Resources, error) {
 		Environment:   cfg.Environment,
 		GCPProject:    cfg.GCPProjectID,
 		DefaultModule: cfg.DefaultModule,
+		RedactKeys:    redactKeysFromEnv(),
 	})
 
 	logger := slog.New(handler)
@@ -78,6 +81,16 @@ func (r *Resources) Logger() *slog.Logger {
 	return r.logger
 }
 
+// MetricsHandler returns the Prometheus scrape handler, or nil when metrics
+// are not exported in pull mode.
+func (r *Resources) MetricsHandler() http.Handler {
+	if r.meterProvider == nil {
+		return nil
+	}
+
+	return r.meterProvider.Handler()
+}
+
 func (r *Resources) Shutdown(ctx context.Context) error {
 	var errs []error
 
@@ -108,7 +121,19 @@ func NewLogger(w io.Writer, cfg Config) *slog.Logger {
 		Environment:   cfg.Environment,
 		GCPProject:    cfg.GCPProjectID,
 		DefaultModule: cfg.DefaultModule,
+		RedactKeys:    redactKeysFromEnv(),
 	})
 
 	return slog.New(handler)
 }
+
+// redactKeysFromEnv returns the attribute keys listed in LOG_REDACT_KEYS,
+// which are redacted in addition to logging.DefaultRedactKeys.
+func redactKeysFromEnv() []string {
+	keys := os.Getenv("LOG_REDACT_KEYS")
+	if keys == "" {
+		return nil
+	}
+
+	return strings.Split(keys, ",")
+}
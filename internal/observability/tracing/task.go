@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.38.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MessagingSystem is the messaging.system attribute value for task spans.
+const MessagingSystem = "asynq"
+
+// TaskLinkMode controls how the span processing a task relates to the span
+// that enqueued it.
+type TaskLinkMode string
+
+const (
+	// TaskLinkParent makes the enqueue span the parent of the process span,
+	// so both share a single trace.
+	TaskLinkParent TaskLinkMode = "parent"
+	// TaskLinkLink starts the process span as a new trace root with a span
+	// link to the enqueue span, which suits tasks scheduled far in the future.
+	TaskLinkLink TaskLinkMode = "link"
+)
+
+// ParseTaskLinkMode returns the mode named by s.
+func ParseTaskLinkMode(s string) (TaskLinkMode, error) {
+	switch mode := TaskLinkMode(s); mode {
+	case TaskLinkParent, TaskLinkLink:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown task link mode %q", s)
+	}
+}
+
+// EnqueueSpanAttributes returns messaging attributes for the producer span.
+func EnqueueSpanAttributes(queueName string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystemKey.String(MessagingSystem),
+		semconv.MessagingOperationTypeSend,
+		semconv.MessagingOperationName("enqueue"),
+		semconv.MessagingDestinationName(queueName),
+	}
+}
+
+// ProcessSpanStartOptions returns options for the consumer span of a task.
+// ctx must carry the enqueue span context restored from the task headers.
+func ProcessSpanStartOptions(ctx context.Context, mode TaskLinkMode, queueName, taskID string) []trace.SpanStartOption {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(MessagingSystem),
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingOperationName("process"),
+			semconv.MessagingDestinationName(queueName),
+			semconv.MessagingMessageID(taskID),
+		),
+	}
+
+	if mode != TaskLinkLink {
+		return opts
+	}
+
+	enqueueSpan := trace.SpanContextFromContext(ctx)
+	if !enqueueSpan.IsValid() {
+		return opts
+	}
+
+	return append(opts,
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.Link{SpanContext: enqueueSpan}),
+	)
+}
@@ -0,0 +1,33 @@
+// Package outbox enqueues tasks that producers write to a task_outbox table
+// in the same transaction as their own data.
+package outbox
+
+import (
+	"time"
+)
+
+// Message is a row of the task_outbox table. Producers insert rows with
+// SentAt unset; the poller enqueues them and sets SentAt.
+type Message struct {
+	// ID is used as the task ID, so a row is enqueued at most once while the
+	// task is retained in the queue
+	ID string `gorm:"primaryKey;size:255"`
+	// Queue is the queue to enqueue into; empty means the default queue
+	Queue string `gorm:"size:255;not null;default:''"`
+	// URL overrides the worker's target endpoint when set
+	URL string `gorm:"size:2048;not null;default:''"`
+	// Headers is a JSON object of string header values
+	Headers      string `gorm:"not null;default:'{}'"`
+	Body         []byte
+	ScheduleTime *time.Time
+	CreatedAt    time.Time  `gorm:"not null;index:idx_task_outbox_pending,priority:2"`
+	SentAt       *time.Time `gorm:"index:idx_task_outbox_pending,priority:1"`
+	// Attempts and LastError describe failed enqueue attempts; rows reaching
+	// the attempt limit are left for inspection
+	Attempts  int    `gorm:"not null;default:0"`
+	LastError string `gorm:"size:1024;not null;default:''"`
+}
+
+func (Message) TableName() string {
+	return "task_outbox"
+}
@@ -0,0 +1,216 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/KasumiMercury/primind-tasks/internal/database"
+	"github.com/KasumiMercury/primind-tasks/internal/errmsg"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+// maxBackoff bounds the wait between polls while enqueueing keeps failing.
+const maxBackoff = time.Minute
+
+// errInvalidMessage marks rows that can never be enqueued as they are. Only
+// these count toward the attempt limit; other failures are retried until
+// the queue is reachable again.
+var errInvalidMessage = errors.New("invalid outbox message")
+
+// Poller moves pending outbox rows into the queue.
+//
+// Each row is marked sent in its own transaction right after its task was
+// enqueued, so a crash or failed commit in between leaves at most that row
+// to be enqueued again on the next poll. The row ID is the task ID and the
+// task is kept for retention after it finished, so that second enqueue
+// fails with a conflict, treated as success. A row enqueued again only
+// after its task finished and the retention passed is delivered twice.
+type Poller struct {
+	db          *gorm.DB
+	client      *queue.Client
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	retention   time.Duration
+}
+
+func NewPoller(db *gorm.DB, client *queue.Client, interval time.Duration, batchSize, maxAttempts int, retention time.Duration) *Poller {
+	return &Poller{
+		db:          db,
+		client:      client,
+		interval:    interval,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		retention:   retention,
+	}
+}
+
+// Run polls until ctx is cancelled. A batch sent in full is followed by the
+// next poll right away; after a failure the wait doubles up to maxBackoff.
+func (p *Poller) Run(ctx context.Context) {
+	wait := p.interval
+
+	for {
+		n, err := p.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to poll outbox",
+				slog.String("event", "outbox.poll.fail"),
+				slog.String("error", err.Error()),
+			)
+		}
+
+		switch {
+		case err != nil:
+			wait = min(wait*2, max(maxBackoff, p.interval))
+		case n == p.batchSize:
+			wait = p.interval
+			continue
+		default:
+			wait = p.interval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Poll enqueues one batch of pending rows and returns the number of rows
+// sent. On Postgres each row is locked with SKIP LOCKED while it is sent,
+// so several pollers can run side by side.
+//
+// Invalid rows use up an attempt and are skipped. Any other enqueue failure
+// ends the batch without using up attempts and is returned, since it most
+// likely hits every row alike.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	var ids []string
+	err := p.db.WithContext(ctx).Model(&Message{}).
+		Where("sent_at IS NULL AND attempts < ?", p.maxAttempts).
+		Order("created_at, id").
+		Limit(p.batchSize).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, id := range ids {
+		ok, err := p.sendRow(ctx, id)
+		if ok {
+			sent++
+		}
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// sendRow enqueues the row id, unless another poller sent or holds it, and
+// records the outcome in the same short transaction. It reports whether the
+// row was sent.
+func (p *Poller) sendRow(ctx context.Context, id string) (bool, error) {
+	sent := false
+	var sendErr error
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Where("id = ? AND sent_at IS NULL", id)
+		if tx.Dialector.Name() == database.DriverPostgres {
+			q = q.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		var messages []Message
+		if err := q.Limit(1).Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		msg := &messages[0]
+
+		err := p.send(msg)
+		if err == nil {
+			if err := tx.Model(msg).Update("sent_at", time.Now().UTC()).Error; err != nil {
+				return err
+			}
+			sent = true
+			return nil
+		}
+
+		invalid := errors.Is(err, errInvalidMessage)
+		attempts := msg.Attempts
+		if invalid {
+			attempts++
+		}
+		slog.WarnContext(ctx, "failed to enqueue outbox message",
+			slog.String("event", "outbox.enqueue.fail"),
+			slog.String("outbox.id", msg.ID),
+			slog.Int("outbox.attempts", attempts),
+			slog.String("error", err.Error()),
+		)
+
+		updates := map[string]any{"last_error": errmsg.Truncate(err.Error())}
+		if invalid {
+			updates["attempts"] = gorm.Expr("attempts + 1")
+		}
+		if err := tx.Model(msg).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if !invalid {
+			sendErr = err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return sent, sendErr
+}
+
+// send enqueues msg, treating an existing task with the same ID as a
+// previous send whose row was not marked yet.
+func (p *Poller) send(msg *Message) error {
+	headers := map[string]string{}
+	if msg.Headers != "" {
+		if err := json.Unmarshal([]byte(msg.Headers), &headers); err != nil {
+			return fmt.Errorf("%w: invalid headers: %v", errInvalidMessage, err)
+		}
+	}
+
+	if msg.URL != "" {
+		u, err := url.Parse(msg.URL)
+		if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("%w: invalid url %q: an absolute http(s) URL is required", errInvalidMessage, msg.URL)
+		}
+	}
+
+	queueName := msg.Queue
+	if queueName == "" {
+		queueName = p.client.DefaultQueueName()
+	}
+
+	payload := queue.NewTaskPayload(msg.Body, headers)
+	payload.URL = msg.URL
+
+	_, err := p.client.EnqueueTaskWithQueue(payload, msg.ScheduleTime, queueName, msg.ID, queue.WithRetention(p.retention))
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+
+	return err
+}
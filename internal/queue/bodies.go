@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// bodiesKeyPrefix prefixes the per-queue Redis keys tracking offloaded
+// bodies: a sorted set of blob keys scored by the time they were tracked,
+// and a hash from blob key to task ID.
+const bodiesKeyPrefix = "primind:bodies:"
+
+// bodyCollectGrace is how long a tracked body is left alone, covering the
+// time between offloading a body and enqueueing its task.
+const bodyCollectGrace = time.Minute
+
+// bodyCollectBatch bounds the blob keys read from Redis at once.
+const bodyCollectBatch = 500
+
+func bodiesKey(queueName string) string {
+	return bodiesKeyPrefix + "{" + queueName + "}"
+}
+
+func bodyTasksKey(queueName string) string {
+	return bodiesKey(queueName) + ":tasks"
+}
+
+// trackBody records the offloaded body of taskID, so CollectBodies deletes
+// it once the task is done. A body already tracked keeps its entry.
+func (c *Client) trackBody(ctx context.Context, queueName, taskID string, ref *BodyRef) error {
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAddNX(ctx, bodiesKey(queueName), redis.Z{Score: float64(time.Now().Unix()), Member: ref.Key})
+		pipe.HSetNX(ctx, bodyTasksKey(queueName), ref.Key, taskID)
+		return nil
+	})
+
+	return err
+}
+
+// TrackBody records the offloaded body of a task being processed. Bodies
+// offloaded by this client are tracked already; this covers tasks enqueued
+// before bodies were tracked.
+func (c *Client) TrackBody(ctx context.Context, queueName, taskID string, payload *TaskPayload) error {
+	if payload.BodyRef == nil || c.store == nil {
+		return nil
+	}
+
+	return c.trackBody(ctx, queueName, taskID, payload.BodyRef)
+}
+
+// RunBodyCollector calls CollectBodies every interval until ctx is
+// cancelled.
+func (c *Client) RunBodyCollector(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := c.CollectBodies(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to collect offloaded bodies",
+				slog.String("event", "payload.collect.fail"),
+				slog.String("error", err.Error()),
+			)
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "deleted offloaded bodies",
+				slog.String("event", "payload.collect"),
+				slog.Int("payload.bodies", n),
+			)
+		}
+	}
+}
+
+// CollectBodies deletes the tracked bodies of tasks that completed or no
+// longer exist, and returns the number deleted. Bodies of archived tasks
+// are kept while the task can still be run again, and deleted once asynq
+// trims it or it is deleted.
+//
+// Bodies are deleted here rather than by the worker, since a worker that
+// stops before asynq acknowledges a delivered task would otherwise run it
+// again without its body.
+func (c *Client) CollectBodies(ctx context.Context) (int, error) {
+	if c.store == nil {
+		return 0, nil
+	}
+
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		return 0, err
+	}
+
+	collected := 0
+	for _, queueName := range queues {
+		n, err := c.collectQueueBodies(ctx, queueName)
+		collected += n
+		if err != nil {
+			return collected, err
+		}
+	}
+
+	return collected, nil
+}
+
+func (c *Client) collectQueueBodies(ctx context.Context, queueName string) (int, error) {
+	collected := 0
+	maxScore := strconv.FormatInt(time.Now().Add(-bodyCollectGrace).Unix(), 10)
+
+	// Kept entries stay in the set, so the next page starts after them
+	var offset int64
+	for {
+		keys, err := c.rdb.ZRangeByScore(ctx, bodiesKey(queueName), &redis.ZRangeBy{
+			Min:    "-inf",
+			Max:    maxScore,
+			Offset: offset,
+			Count:  bodyCollectBatch,
+		}).Result()
+		if err != nil {
+			return collected, err
+		}
+
+		for _, key := range keys {
+			done, err := c.bodyTaskDone(ctx, queueName, key)
+			if err != nil {
+				return collected, err
+			}
+			if !done {
+				offset++
+				continue
+			}
+
+			if err := c.store.Delete(ctx, key); err != nil {
+				return collected, err
+			}
+			_, err = c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.ZRem(ctx, bodiesKey(queueName), key)
+				pipe.HDel(ctx, bodyTasksKey(queueName), key)
+				return nil
+			})
+			if err != nil {
+				return collected, err
+			}
+			collected++
+		}
+
+		if len(keys) < bodyCollectBatch {
+			return collected, nil
+		}
+	}
+}
+
+// bodyTaskDone reports whether the task owning the body stored under key
+// completed or no longer exists.
+func (c *Client) bodyTaskDone(ctx context.Context, queueName, key string) (bool, error) {
+	taskID, err := c.rdb.HGet(ctx, bodyTasksKey(queueName), key).Result()
+	if errors.Is(err, redis.Nil) {
+		// Tracked without a task, which cannot happen unless the hash
+		// entry was lost; nothing can refer to the body
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	info, err := c.inspector.GetTaskInfo(queueName, taskID)
+	if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return info.State == asynq.TaskStateCompleted, nil
+}
@@ -1,46 +1,286 @@
 package queue
 
 import (
+	"context"
+	"errors"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/KasumiMercury/primind-tasks/internal/blobstore"
 	"github.com/KasumiMercury/primind-tasks/internal/config"
+	"github.com/KasumiMercury/primind-tasks/internal/envelope"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+	"github.com/KasumiMercury/primind-tasks/internal/labels"
 )
 
+// ErrLabelIndexDisabled is returned by FindTasks when the client has no
+// label index.
+var ErrLabelIndexDisabled = errors.New("label index is not configured")
+
 type Client struct {
-	client     *asynq.Client
-	inspector  *asynq.Inspector
+	client    *asynq.Client
+	inspector *asynq.Inspector
+	// rdb accesses asynq's task hashes directly for in-place updates
+	rdb        redis.UniversalClient
 	queueName  string
 	retryCount int
+	retention  time.Duration
+
+	keyring          *envelope.Keyring
+	sensitiveHeaders []string
+
+	store          blobstore.Store
+	storeThreshold int
+
+	compression          string
+	compressionThreshold int
+
+	history *history.Sink
+	events  *events.Stream
+	labels  *labels.Index
+	dedup   *dedupConfig
 }
 
-func NewClient(cfg *config.Config) *Client {
-	redisOpt := asynq.RedisClientOpt{
-		Addr:     cfg.RedisAddr,
-		Password: cfg.RedisPassword,
-		DB:       cfg.RedisDB,
+// ClientOption configures optional behavior of the Client.
+type ClientOption func(*Client)
+
+// WithPayloadEncryption seals the body and sensitiveHeaders of every enqueued
+// payload with keyring.
+func WithPayloadEncryption(keyring *envelope.Keyring, sensitiveHeaders []string) ClientOption {
+	return func(c *Client) {
+		c.keyring = keyring
+		c.sensitiveHeaders = sensitiveHeaders
+	}
+}
+
+// WithPayloadStore offloads bodies larger than threshold bytes to store.
+func WithPayloadStore(store blobstore.Store, threshold int) ClientOption {
+	return func(c *Client) {
+		c.store = store
+		c.storeThreshold = threshold
+	}
+}
+
+// WithPayloadCompression compresses bodies larger than threshold bytes with
+// encoding (BodyEncodingGzip or BodyEncodingZstd).
+func WithPayloadCompression(encoding string, threshold int) ClientOption {
+	return func(c *Client) {
+		c.compression = encoding
+		c.compressionThreshold = threshold
+	}
+}
+
+// WithHistory records task creation and deletion in sink.
+func WithHistory(sink *history.Sink) ClientOption {
+	return func(c *Client) {
+		c.history = sink
+	}
+}
+
+// WithEvents publishes task enqueues and deletions to stream.
+func WithEvents(stream *events.Stream) ClientOption {
+	return func(c *Client) {
+		c.events = stream
+	}
+}
+
+// WithLabelIndex indexes the labels of enqueued tasks in index.
+func WithLabelIndex(index *labels.Index) ClientOption {
+	return func(c *Client) {
+		c.labels = index
+	}
+}
+
+// WithDeduplication deduplicates the unnamed tasks of queues by content: a
+// task identical to one enqueued within window (same target, body and
+// headers) is rejected, or answered with the existing task when mode is
+// DedupExisting.
+func WithDeduplication(queues []string, window time.Duration, headers []string, mode string) ClientOption {
+	names := make([]string, 0, len(headers))
+	for _, h := range headers {
+		names = append(names, strings.ToLower(h))
+	}
+	slices.Sort(names)
+
+	return func(c *Client) {
+		c.dedup = &dedupConfig{
+			queues:  queues,
+			window:  window,
+			headers: slices.Compact(names),
+			mode:    mode,
+		}
+	}
+}
+
+// EnqueueOption overrides a client default for a single task.
+type EnqueueOption func(*enqueueOptions)
+
+type enqueueOptions struct {
+	maxRetry  int
+	retention time.Duration
+}
+
+// WithMaxRetry sets how many times the task is retried instead of the
+// configured retry count.
+func WithMaxRetry(n int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.maxRetry = n
+	}
+}
+
+// WithRetention keeps the task for at least d after it finished, so its ID
+// keeps conflicting with new tasks even without a configured result
+// retention.
+func WithRetention(d time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.retention = max(o.retention, d)
 	}
-	return &Client{
+}
+
+func NewClient(cfg *config.Config, opts ...ClientOption) *Client {
+	redisOpt := RedisClientOpt(cfg)
+	c := &Client{
 		client:     asynq.NewClient(redisOpt),
 		inspector:  asynq.NewInspector(redisOpt),
+		rdb:        NewRedisClient(cfg),
 		queueName:  cfg.QueueName,
 		retryCount: cfg.RetryCount,
+		retention:  cfg.TaskResultRetention,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// RedisClientOpt builds the asynq Redis connection options from the config.
+func RedisClientOpt(cfg *config.Config) asynq.RedisClientOpt {
+	return asynq.RedisClientOpt{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	}
+}
+
+// NewRedisClient returns a Redis client using the same connection options as
+// the asynq client, for data asynq does not manage itself.
+func NewRedisClient(cfg *config.Config) redis.UniversalClient {
+	return RedisClientOpt(cfg).MakeRedisClient().(redis.UniversalClient)
+}
+
+// NewRedisClientWithPoolSize is NewRedisClient with a pool of poolSize
+// connections (the go-redis default when 0), for callers holding connections
+// for long, such as blocking reads.
+func NewRedisClientWithPoolSize(cfg *config.Config, poolSize int) redis.UniversalClient {
+	opt := RedisClientOpt(cfg)
+	opt.PoolSize = poolSize
+
+	return opt.MakeRedisClient().(redis.UniversalClient)
 }
 
 func (c *Client) Close() error {
 	if err := c.inspector.Close(); err != nil {
 		return err
 	}
+	if err := c.rdb.Close(); err != nil {
+		return err
+	}
 	return c.client.Close()
 }
 
+// Inspector returns the underlying asynq inspector.
+func (c *Client) Inspector() *asynq.Inspector {
+	return c.inspector
+}
+
 func (c *Client) DefaultQueueName() string {
 	return c.queueName
 }
 
+// GetTask returns the state and latest result of a task.
+func (c *Client) GetTask(queueName, taskID string) (*asynq.TaskInfo, error) {
+	return c.inspector.GetTaskInfo(queueName, taskID)
+}
+
+// FindTasks returns the tasks of a queue having all labels of selector.
+// Index entries of tasks that no longer exist, or whose ID was reused by a
+// task with other labels, are removed along the way.
+func (c *Client) FindTasks(ctx context.Context, queueName string, selector map[string]string) ([]*asynq.TaskInfo, error) {
+	if c.labels == nil {
+		return nil, ErrLabelIndexDisabled
+	}
+
+	ids, err := c.labels.Find(ctx, queueName, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*asynq.TaskInfo, 0, len(ids))
+	for _, id := range ids {
+		info, err := c.inspector.GetTaskInfo(queueName, id)
+		if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+			c.dropStaleLabels(ctx, queueName, id, selector, nil)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		payload, err := UnmarshalTaskPayload(info.Payload)
+		if err != nil {
+			continue
+		}
+		if !hasLabels(payload.Labels, selector) {
+			c.dropStaleLabels(ctx, queueName, id, selector, payload.Labels)
+			continue
+		}
+
+		tasks = append(tasks, info)
+	}
+
+	return tasks, nil
+}
+
+// dropStaleLabels removes the entries of selector that do not match labels,
+// the actual labels of the task (nil for a missing task).
+func (c *Client) dropStaleLabels(ctx context.Context, queueName, taskID string, selector, labels map[string]string) {
+	stale := make(map[string]string, len(selector))
+	for k, v := range selector {
+		if actual, ok := labels[k]; !ok || actual != v {
+			stale[k] = v
+		}
+	}
+
+	if err := c.labels.Remove(ctx, queueName, taskID, stale); err != nil {
+		log.Printf("warning: could not remove task %s from the label index: %v", taskID, err)
+	}
+}
+
+func hasLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if actual, ok := labels[k]; !ok || actual != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RunTask moves a scheduled, retry or archived task to pending, so it is
+// processed right away.
+func (c *Client) RunTask(queueName, taskID string) error {
+	return c.inspector.RunTask(queueName, taskID)
+}
+
 func (c *Client) DeleteTask(taskID string) error {
 	return c.DeleteTaskFromQueue(c.queueName, taskID)
 }
@@ -58,35 +298,207 @@ func (c *Client) DeleteTaskFromQueue(queueName, taskID string) error {
 		return nil
 	}
 
-	return c.inspector.DeleteTask(queueName, taskID)
+	if err := c.inspector.DeleteTask(queueName, taskID); err != nil {
+		return err
+	}
+
+	c.deleteOffloadedBody(info)
+	c.unindexLabels(info)
+
+	c.history.Record(context.Background(), history.Event{
+		TaskID: taskID,
+		Queue:  queueName,
+		Type:   history.EventDeleted,
+	})
+	c.events.Publish(context.Background(), events.Event{
+		Type:   events.TypeDeleted,
+		TaskID: taskID,
+		Queue:  queueName,
+	})
+
+	return nil
+}
+
+// unindexLabels removes a deleted task from the label index. Entries left
+// behind are skipped when found, so failures are only logged.
+func (c *Client) unindexLabels(info *asynq.TaskInfo) {
+	if c.labels == nil {
+		return
+	}
+
+	payload, err := UnmarshalTaskPayload(info.Payload)
+	if err != nil {
+		return
+	}
+
+	if err := c.labels.Remove(context.Background(), info.Queue, info.ID, payload.Labels); err != nil {
+		log.Printf("warning: could not remove task %s from the label index: %v", info.ID, err)
+	}
 }
 
-func (c *Client) EnqueueTask(payload *TaskPayload, scheduleTime *time.Time, taskID string) (*asynq.TaskInfo, error) {
-	return c.EnqueueTaskWithQueue(payload, scheduleTime, c.queueName, taskID)
+// deleteOffloadedBody removes the blob referenced by a deleted task. Failures
+// only leave an orphaned blob behind, so they are logged and ignored.
+func (c *Client) deleteOffloadedBody(info *asynq.TaskInfo) {
+	if c.store == nil {
+		return
+	}
+
+	payload, err := UnmarshalTaskPayload(info.Payload)
+	if err != nil {
+		return
+	}
+
+	if err := payload.DeleteBody(context.Background(), c.store); err != nil {
+		log.Printf("warning: could not delete offloaded body of task %s: %v", info.ID, err)
+	}
 }
 
-func (c *Client) EnqueueTaskWithQueue(payload *TaskPayload, scheduleTime *time.Time, queueName string, taskID string) (*asynq.TaskInfo, error) {
-	data, err := payload.Marshal()
+func (c *Client) EnqueueTask(payload *TaskPayload, scheduleTime *time.Time, taskID string, opts ...EnqueueOption) (*asynq.TaskInfo, error) {
+	return c.EnqueueTaskWithQueue(payload, scheduleTime, c.queueName, taskID, opts...)
+}
+
+func (c *Client) EnqueueTaskWithQueue(payload *TaskPayload, scheduleTime *time.Time, queueName string, taskID string, opts ...EnqueueOption) (*asynq.TaskInfo, error) {
+	o := enqueueOptions{maxRetry: c.retryCount, retention: c.retention}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Named tasks conflict by name; only unnamed tasks are deduplicated by
+	// content, before the payload is compressed or sealed
+	enqueued := false
+	if taskID == "" && c.dedupEnabled(queueName) {
+		taskID = uuid.NewString()
+		key, existing, err := c.claimDedup(context.Background(), queueName, payload, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != "" {
+			return c.duplicateTask(queueName, existing)
+		}
+		defer func() {
+			if !enqueued {
+				c.releaseDedup(key, taskID)
+			}
+		}()
+	}
+
+	// Offloaded bodies are tracked by task ID and sealed payloads are bound
+	// to it, so the ID is chosen here
+	if taskID == "" {
+		taskID = uuid.NewString()
+	}
+
+	data, offloaded, err := c.encodePayload(payload, queueName, taskID, true)
 	if err != nil {
+		if offloaded {
+			c.discardOffloadedBody(payload)
+		}
 		return nil, err
 	}
 
 	task := asynq.NewTask(TaskTypeHTTPForward, data)
 
-	opts := []asynq.Option{
+	taskOpts := []asynq.Option{
 		asynq.Queue(queueName),
-		asynq.MaxRetry(c.retryCount),
+		asynq.MaxRetry(o.maxRetry),
 	}
 
-	if taskID != "" {
-		opts = append(opts, asynq.TaskID(taskID))
+	// Completed tasks are kept for the retention period so that the result
+	// written by the worker can still be read
+	if o.retention > 0 {
+		taskOpts = append(taskOpts, asynq.Retention(o.retention))
 	}
 
+	// Labels are indexed before the task exists, so a task is never
+	// missing from the index; entries of tasks that failed to enqueue are
+	// skipped when found
+	if len(payload.Labels) > 0 && c.labels != nil {
+		if err := c.labels.Add(context.Background(), queueName, taskID, payload.Labels); err != nil {
+			if offloaded {
+				c.discardOffloadedBody(payload)
+			}
+			return nil, err
+		}
+	}
+
+	taskOpts = append(taskOpts, asynq.TaskID(taskID))
+
 	if scheduleTime != nil && scheduleTime.After(time.Now()) {
-		opts = append(opts, asynq.ProcessAt(*scheduleTime))
+		taskOpts = append(taskOpts, asynq.ProcessAt(*scheduleTime))
+	}
+
+	info, err := c.client.Enqueue(task, taskOpts...)
+	if err != nil {
+		if offloaded {
+			c.discardOffloadedBody(payload)
+		}
+		return nil, err
+	}
+	enqueued = true
+
+	c.history.Record(context.Background(), history.Event{
+		TaskID: info.ID,
+		Queue:  queueName,
+		Type:   history.EventCreated,
+	})
+	c.events.Publish(context.Background(), events.Event{
+		Type:   events.TypeEnqueued,
+		TaskID: info.ID,
+		Queue:  queueName,
+	})
+
+	return info, nil
+}
+
+// discardOffloadedBody removes the blob of a payload that was never enqueued.
+func (c *Client) discardOffloadedBody(payload *TaskPayload) {
+	if err := payload.DeleteBody(context.Background(), c.store); err != nil {
+		log.Printf("warning: could not delete offloaded body after enqueue failure: %v", err)
+	}
+}
+
+// EncodePayload compresses, seals and marshals payload like
+// EnqueueTaskWithQueue, for tasks later enqueued to queueName. The body is
+// never offloaded, since the result may be enqueued repeatedly (e.g. by a
+// schedule) and offloaded bodies are deleted once the first task is done.
+func (c *Client) EncodePayload(payload *TaskPayload, queueName string) ([]byte, error) {
+	data, _, err := c.encodePayload(payload, queueName, "", false)
+	return data, err
+}
+
+func (c *Client) encodePayload(payload *TaskPayload, queueName, taskID string, allowOffload bool) ([]byte, bool, error) {
+	// Compress first: sealed bodies are incompressible and offloaded bodies
+	// benefit from the smaller size as well
+	if c.compression != "" {
+		if err := payload.Compress(c.compression, c.compressionThreshold); err != nil {
+			return nil, false, err
+		}
+	}
+
+	offloaded := false
+	if allowOffload && c.store != nil && len(payload.Body) > c.storeThreshold && payload.BodyRef == nil {
+		if err := payload.Offload(context.Background(), c.store, c.keyring, queueName+"/"+uuid.NewString()); err != nil {
+			return nil, false, err
+		}
+		offloaded = true
+
+		if err := c.trackBody(context.Background(), queueName, taskID, payload.BodyRef); err != nil {
+			return nil, offloaded, err
+		}
+	}
+
+	if c.keyring != nil {
+		if err := payload.Seal(c.keyring, c.sensitiveHeaders, queueName, taskID); err != nil {
+			return nil, offloaded, err
+		}
+	}
+
+	data, err := payload.Marshal()
+	if err != nil {
+		return nil, offloaded, err
 	}
 
-	return c.client.Enqueue(task, opts...)
+	return data, offloaded, nil
 }
 
 // Ping checks if the Redis connection is healthy by listing queues.
@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Body encodings recorded in TaskPayload.BodyEncoding. They double as HTTP
+// content-coding names so a compressed body can be forwarded as is.
+const (
+	BodyEncodingGzip = "gzip"
+	BodyEncodingZstd = "zstd"
+)
+
+// SupportedBodyEncoding reports whether encoding can be used for compression.
+func SupportedBodyEncoding(encoding string) bool {
+	return encoding == BodyEncodingGzip || encoding == BodyEncodingZstd
+}
+
+// Compress compresses the body with encoding when it is larger than
+// threshold bytes. Bodies the producer already encoded (Content-Encoding
+// header) are left untouched, as are bodies that do not shrink.
+func (p *TaskPayload) Compress(encoding string, threshold int) error {
+	if p.BodyEncoding != "" || len(p.Body) <= threshold || headerFold(p.Headers, "Content-Encoding") != "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	switch encoding {
+	case BodyEncodingGzip:
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(p.Body); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	case BodyEncodingZstd:
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return err
+		}
+		if _, err := zw.Write(p.Body); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported body encoding %q", encoding)
+	}
+
+	if buf.Len() >= len(p.Body) {
+		return nil
+	}
+
+	p.Body = buf.Bytes()
+	p.BodyEncoding = encoding
+
+	return nil
+}
+
+// Decompress restores the original body. Payloads without a BodyEncoding,
+// including those enqueued before compression existed, are left as is.
+func (p *TaskPayload) Decompress() error {
+	var (
+		r   io.Reader
+		err error
+	)
+	switch p.BodyEncoding {
+	case "":
+		return nil
+	case BodyEncodingGzip:
+		r, err = gzip.NewReader(bytes.NewReader(p.Body))
+	case BodyEncodingZstd:
+		var zr *zstd.Decoder
+		if zr, err = zstd.NewReader(bytes.NewReader(p.Body)); err == nil {
+			defer zr.Close()
+			r = zr
+		}
+	default:
+		return fmt.Errorf("unsupported body encoding %q", p.BodyEncoding)
+	}
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	p.Body = body
+	p.BodyEncoding = ""
+
+	return nil
+}
+
+func headerFold(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
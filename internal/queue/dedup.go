@@ -0,0 +1,147 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// Deduplication modes, selecting what EnqueueTaskWithQueue does when an
+// identical task was enqueued within the window.
+const (
+	// DedupReject fails the enqueue with a DuplicateTaskError
+	DedupReject = "reject"
+	// DedupExisting returns the existing task instead
+	DedupExisting = "existing"
+)
+
+// dedupKeyPrefix prefixes the Redis key holding the ID of the task enqueued
+// with a given content hash.
+const dedupKeyPrefix = "primind:dedup:"
+
+// dedupClaimAttempts bounds the retries of a claim racing with the expiry
+// of the key it found.
+const dedupClaimAttempts = 2
+
+// releaseDedupKey deletes a content hash claim, unless it was taken over by
+// another task in the meantime.
+var releaseDedupKey = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// DuplicateTaskError is returned by EnqueueTaskWithQueue when an identical
+// task was enqueued within the deduplication window.
+type DuplicateTaskError struct {
+	// TaskID is the ID of the task enqueued first
+	TaskID string
+}
+
+func (e *DuplicateTaskError) Error() string {
+	return fmt.Sprintf("an identical task %s was enqueued within the deduplication window", e.TaskID)
+}
+
+// SupportedDedupMode reports whether mode is DedupReject or DedupExisting.
+func SupportedDedupMode(mode string) bool {
+	return mode == DedupReject || mode == DedupExisting
+}
+
+type dedupConfig struct {
+	queues  []string
+	window  time.Duration
+	headers []string
+	mode    string
+}
+
+// dedupEnabled reports whether unnamed tasks of queueName are deduplicated.
+func (c *Client) dedupEnabled(queueName string) bool {
+	return c.dedup != nil && slices.Contains(c.dedup.queues, queueName)
+}
+
+// contentHash digests what a task delivers: the target, the method, the
+// body and the configured headers. It must be computed before the payload
+// is compressed or sealed.
+func (d *dedupConfig) contentHash(payload *TaskPayload) string {
+	h := sha256.New()
+	write := func(s []byte) {
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(s))))
+		h.Write(s)
+	}
+
+	// Tasks are always delivered with POST
+	write([]byte(http.MethodPost))
+	write([]byte(payload.URL))
+	write(payload.Body)
+	for _, name := range d.headers {
+		write([]byte(name))
+		write([]byte(headerFold(payload.Headers, name)))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// claimDedup reserves the content hash of payload for taskID during the
+// window. When an identical task holds it already, its ID is returned
+// instead.
+func (c *Client) claimDedup(ctx context.Context, queueName string, payload *TaskPayload, taskID string) (key, existing string, err error) {
+	key = fmt.Sprintf("%s{%s}:%s", dedupKeyPrefix, queueName, c.dedup.contentHash(payload))
+
+	for range dedupClaimAttempts {
+		claimed, err := c.rdb.SetNX(ctx, key, taskID, c.dedup.window).Result()
+		if err != nil {
+			return "", "", err
+		}
+		if claimed {
+			return key, "", nil
+		}
+
+		existing, err = c.rdb.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			// Expired since SETNX
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		return key, existing, nil
+	}
+
+	return "", "", fmt.Errorf("could not claim deduplication key %s", key)
+}
+
+// releaseDedup frees the content hash of a task that failed to enqueue, so
+// retrying it is not taken for a duplicate.
+func (c *Client) releaseDedup(key, taskID string) {
+	if err := releaseDedupKey.Run(context.Background(), c.rdb, []string{key}, taskID).Err(); err != nil {
+		log.Printf("warning: could not release deduplication key of task %s: %v", taskID, err)
+	}
+}
+
+// duplicateTask answers the enqueue of a task identical to existing.
+func (c *Client) duplicateTask(queueName, existing string) (*asynq.TaskInfo, error) {
+	if c.dedup.mode == DedupExisting {
+		info, err := c.inspector.GetTaskInfo(queueName, existing)
+		if err == nil {
+			return info, nil
+		}
+		// A task deleted or purged since is still reported as a duplicate
+		if !errors.Is(err, asynq.ErrTaskNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, &DuplicateTaskError{TaskID: existing}
+}
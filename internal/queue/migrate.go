@@ -0,0 +1,171 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of asynq's internal TaskMessage protobuf.
+const (
+	taskMessageTypeField    protowire.Number = 1
+	taskMessagePayloadField protowire.Number = 2
+)
+
+// compareAndSetMsg replaces the task message only if it was not modified
+// (e.g. by a worker retrying the task) since it was read.
+var compareAndSetMsg = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "msg") == ARGV[1] then
+	redis.call("HSET", KEYS[1], "msg", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// MigrationResult counts the tasks visited by MigrateLegacyPayloads.
+type MigrationResult struct {
+	Scanned  int
+	Migrated int
+	// Conflicts are tasks modified concurrently; rerunning picks them up
+	Conflicts int
+	Failed    int
+}
+
+// MigrateLegacyPayloads rewrites http:forward tasks of queueName whose payload
+// still uses the legacy JSON encoding. Task messages are rewritten in place
+// in asynq's Redis task hashes, so task state, retry counts and schedules are
+// preserved. With dryRun the tasks are only counted.
+func MigrateLegacyPayloads(ctx context.Context, rdb redis.UniversalClient, queueName string, dryRun bool) (MigrationResult, error) {
+	var result MigrationResult
+
+	prefix := fmt.Sprintf("asynq:{%s}:t:", queueName)
+	iter := rdb.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+
+		msg, err := rdb.HGet(ctx, key, "msg").Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+
+		taskType, payload, err := taskMessageFields(msg)
+		if err != nil || taskType != TaskTypeHTTPForward {
+			continue
+		}
+		result.Scanned++
+
+		if !IsLegacyPayload(payload) {
+			continue
+		}
+
+		migrated, err := migratePayload(payload)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+
+		if dryRun {
+			result.Migrated++
+			continue
+		}
+
+		newMsg, err := replaceTaskMessagePayload(msg, migrated)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+
+		swapped, err := compareAndSetMsg.Run(ctx, rdb, []string{key}, msg, newMsg).Int()
+		if err != nil {
+			return result, err
+		}
+		if swapped == 1 {
+			result.Migrated++
+		} else {
+			result.Conflicts++
+		}
+	}
+
+	return result, iter.Err()
+}
+
+func migratePayload(data []byte) ([]byte, error) {
+	payload, err := UnmarshalTaskPayload(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload.Marshal()
+}
+
+// taskMessageFields extracts the task type and payload from an encoded
+// asynq TaskMessage.
+func taskMessageFields(msg []byte) (string, []byte, error) {
+	var (
+		taskType string
+		payload  []byte
+	)
+
+	for b := msg; len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if typ == protowire.BytesType && (num == taskMessageTypeField || num == taskMessagePayloadField) {
+			v, m := protowire.ConsumeBytes(b)
+			if m < 0 {
+				return "", nil, protowire.ParseError(m)
+			}
+			if num == taskMessageTypeField {
+				taskType = string(v)
+			} else {
+				payload = v
+			}
+			b = b[m:]
+			continue
+		}
+
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return "", nil, protowire.ParseError(m)
+		}
+		b = b[m:]
+	}
+
+	return taskType, payload, nil
+}
+
+// replaceTaskMessagePayload re-encodes msg with payload, keeping every other
+// field byte for byte.
+func replaceTaskMessagePayload(msg, payload []byte) ([]byte, error) {
+	out := make([]byte, 0, len(msg)+len(payload))
+
+	for b := msg; len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		m := protowire.ConsumeFieldValue(num, typ, b[n:])
+		if m < 0 {
+			return nil, protowire.ParseError(m)
+		}
+
+		if num == taskMessagePayloadField && typ == protowire.BytesType {
+			out = protowire.AppendTag(out, num, typ)
+			out = protowire.AppendBytes(out, payload)
+		} else {
+			out = append(out, b[:n+m]...)
+		}
+		b = b[n+m:]
+	}
+
+	return out, nil
+}
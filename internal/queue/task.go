@@ -1,16 +1,80 @@
 package queue
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/KasumiMercury/primind-tasks/internal/blobstore"
+	"github.com/KasumiMercury/primind-tasks/internal/envelope"
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
 )
 
 const TaskTypeHTTPForward = "http:forward"
 
+// Payload encoding versions, stored in the first byte of the task payload.
+// Legacy payloads are JSON objects and therefore start with '{'.
+const (
+	payloadVersionProto      byte = 0x01
+	payloadVersionLegacyJSON byte = '{'
+)
+
+// ErrUnsupportedPayloadVersion is returned for payloads in an unknown encoding.
+var ErrUnsupportedPayloadVersion = errors.New("unsupported task payload version")
+
+// ErrPayloadSealed is returned when a sealed payload is read without a keyring.
+var ErrPayloadSealed = errors.New("task payload is encrypted but no encryption key is configured")
+
+// ErrPayloadStoreMissing is returned when an offloaded body is read without a
+// payload store.
+var ErrPayloadStoreMissing = errors.New("task body is offloaded but no payload store is configured")
+
+// TaskPayload is the payload of an http:forward task. It is stored as
+// taskqueuev1.TaskPayload; the JSON tags describe the legacy encoding.
 type TaskPayload struct {
 	Body      []byte            `json:"body"`
 	Headers   map[string]string `json:"headers"`
 	CreatedAt time.Time         `json:"created_at"`
+	// Sealed holds the encrypted body and sensitive headers; Body is empty and
+	// those headers are removed from Headers while it is set
+	Sealed *envelope.Envelope `json:"sealed,omitempty"`
+	// BodyRef points to a body offloaded to the payload store; Body is empty
+	// while it is set
+	BodyRef *BodyRef `json:"body_ref,omitempty"`
+	// BodyEncoding names the compression applied to the body; empty means
+	// the body is stored as sent
+	BodyEncoding string `json:"body_encoding,omitempty"`
+	// URL overrides the worker's target endpoint when set
+	URL string `json:"url,omitempty"`
+	// OnSuccess and OnFailure are encoded payloads of follow-up tasks
+	// enqueued after this task succeeds or finally fails
+	OnSuccess []byte `json:"on_success,omitempty"`
+	OnFailure []byte `json:"on_failure,omitempty"`
+	// WorkflowID and WorkflowNode identify the workflow node this task runs
+	WorkflowID   string `json:"workflow_id,omitempty"`
+	WorkflowNode string `json:"workflow_node,omitempty"`
+	// Labels are indexed by the label index for search and batch operations
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// BodyRef references a task body kept in a blobstore.Store.
+type BodyRef struct {
+	Key  string `json:"key"`
+	Size int    `json:"size"`
+	// Sealed reports that the blob is an encrypted envelope
+	Sealed bool `json:"sealed,omitempty"`
+}
+
+// sealedContent is the plaintext stored in TaskPayload.Sealed.
+type sealedContent struct {
+	Body    []byte            `json:"body"`
+	Headers map[string]string `json:"headers,omitempty"`
 }
 
 func NewTaskPayload(body []byte, headers map[string]string) *TaskPayload {
@@ -24,14 +88,286 @@ func NewTaskPayload(body []byte, headers map[string]string) *TaskPayload {
 	}
 }
 
+// Marshal encodes the payload as a version byte followed by the
+// taskqueuev1.TaskPayload protobuf message.
 func (p *TaskPayload) Marshal() ([]byte, error) {
-	return json.Marshal(p)
+	msg := &taskqueuev1.TaskPayload{
+		Body:         p.Body,
+		Headers:      p.Headers,
+		CreatedAt:    timestamppb.New(p.CreatedAt),
+		BodyEncoding: p.BodyEncoding,
+		Url:          p.URL,
+		OnSuccess:    p.OnSuccess,
+		OnFailure:    p.OnFailure,
+		WorkflowId:   p.WorkflowID,
+		WorkflowNode: p.WorkflowNode,
+		Labels:       p.Labels,
+	}
+	if p.Sealed != nil {
+		msg.Sealed = &taskqueuev1.SealedEnvelope{
+			KeyId:      p.Sealed.KeyID,
+			WrappedKey: p.Sealed.WrappedKey,
+			Ciphertext: p.Sealed.Ciphertext,
+		}
+	}
+	if p.BodyRef != nil {
+		msg.BodyRef = &taskqueuev1.PayloadBodyRef{
+			Key:    p.BodyRef.Key,
+			Size:   int64(p.BodyRef.Size),
+			Sealed: p.BodyRef.Sealed,
+		}
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{payloadVersionProto}, data...), nil
 }
 
+// UnmarshalTaskPayload decodes payloads written by Marshal as well as legacy
+// JSON payloads enqueued before the protobuf encoding.
 func UnmarshalTaskPayload(data []byte) (*TaskPayload, error) {
-	var p TaskPayload
-	if err := json.Unmarshal(data, &p); err != nil {
-		return nil, err
+	if len(data) == 0 {
+		return nil, ErrUnsupportedPayloadVersion
+	}
+
+	switch data[0] {
+	case payloadVersionLegacyJSON:
+		var p TaskPayload
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		return &p, nil
+	case payloadVersionProto:
+		var msg taskqueuev1.TaskPayload
+		if err := proto.Unmarshal(data[1:], &msg); err != nil {
+			return nil, err
+		}
+		return payloadFromProto(&msg), nil
+	default:
+		return nil, fmt.Errorf("%w: %#x", ErrUnsupportedPayloadVersion, data[0])
+	}
+}
+
+// IsLegacyPayload reports whether data uses the legacy JSON encoding.
+func IsLegacyPayload(data []byte) bool {
+	return len(data) > 0 && data[0] == payloadVersionLegacyJSON
+}
+
+func payloadFromProto(msg *taskqueuev1.TaskPayload) *TaskPayload {
+	p := &TaskPayload{
+		Body:         msg.GetBody(),
+		Headers:      msg.GetHeaders(),
+		BodyEncoding: msg.GetBodyEncoding(),
+		URL:          msg.GetUrl(),
+		OnSuccess:    msg.GetOnSuccess(),
+		OnFailure:    msg.GetOnFailure(),
+		WorkflowID:   msg.GetWorkflowId(),
+		WorkflowNode: msg.GetWorkflowNode(),
+		Labels:       msg.GetLabels(),
+	}
+	if p.Headers == nil {
+		p.Headers = map[string]string{}
+	}
+	if msg.GetCreatedAt() != nil {
+		p.CreatedAt = msg.GetCreatedAt().AsTime()
+	}
+	if sealed := msg.GetSealed(); sealed != nil {
+		p.Sealed = &envelope.Envelope{
+			KeyID:      sealed.GetKeyId(),
+			WrappedKey: sealed.GetWrappedKey(),
+			Ciphertext: sealed.GetCiphertext(),
+		}
+	}
+	if ref := msg.GetBodyRef(); ref != nil {
+		p.BodyRef = &BodyRef{
+			Key:    ref.GetKey(),
+			Size:   int(ref.GetSize()),
+			Sealed: ref.GetSealed(),
+		}
+	}
+
+	return p
+}
+
+// Seal encrypts the body and the headers named in sensitiveHeaders
+// (case-insensitive) so they are not stored in plain text. The envelope is
+// bound to the queue and task ID, or to the queue only for templates sealed
+// before the task ID is known.
+func (p *TaskPayload) Seal(keyring *envelope.Keyring, sensitiveHeaders []string, queueName, taskID string) error {
+	if p.Sealed != nil {
+		return nil
+	}
+
+	content := sealedContent{Body: p.Body}
+	for k, v := range p.Headers {
+		if !containsFold(sensitiveHeaders, k) {
+			continue
+		}
+		if content.Headers == nil {
+			content.Headers = map[string]string{}
+		}
+		content.Headers[k] = v
+	}
+
+	plaintext, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	bindings := []string{queueName}
+	if taskID != "" {
+		bindings = append(bindings, taskID)
+	}
+	sealed, err := keyring.Seal(plaintext, bindings...)
+	if err != nil {
+		return err
+	}
+
+	for k := range content.Headers {
+		delete(p.Headers, k)
+	}
+	p.Body = nil
+	p.Sealed = sealed
+
+	return nil
+}
+
+// Open decrypts a sealed payload of the task taskID in queueName in place.
+// Templates sealed for the queue only are opened as well, and so are
+// payloads sealed before envelopes were bound. It is a no-op for plain
+// payloads.
+func (p *TaskPayload) Open(keyring *envelope.Keyring, queueName, taskID string) error {
+	if p.Sealed == nil {
+		return nil
+	}
+	if keyring == nil {
+		return ErrPayloadSealed
+	}
+
+	candidates := [][]string{{queueName}, nil}
+	if taskID != "" {
+		candidates = append([][]string{{queueName, taskID}}, candidates...)
+	}
+	plaintext, err := openEnvelope(keyring, p.Sealed, candidates...)
+	if err != nil {
+		return err
+	}
+
+	var content sealedContent
+	if err := json.Unmarshal(plaintext, &content); err != nil {
+		return err
+	}
+
+	if p.Headers == nil {
+		p.Headers = map[string]string{}
+	}
+	for k, v := range content.Headers {
+		p.Headers[k] = v
+	}
+	p.Body = content.Body
+	p.Sealed = nil
+
+	return nil
+}
+
+// Offload writes the body to store under key and replaces it with a
+// reference. With a keyring the blob is encrypted as well, bound to key.
+func (p *TaskPayload) Offload(ctx context.Context, store blobstore.Store, keyring *envelope.Keyring, key string) error {
+	ref := &BodyRef{Key: key, Size: len(p.Body)}
+
+	data := p.Body
+	if keyring != nil {
+		sealed, err := keyring.Seal(p.Body, key)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(sealed); err != nil {
+			return err
+		}
+		ref.Sealed = true
+	}
+
+	if err := store.Put(ctx, key, data); err != nil {
+		return err
+	}
+
+	p.Body = nil
+	p.BodyRef = ref
+
+	return nil
+}
+
+// Load fetches an offloaded body back into Body. It is a no-op for inline
+// bodies; the blob itself is left in place until DeleteBody.
+func (p *TaskPayload) Load(ctx context.Context, store blobstore.Store, keyring *envelope.Keyring) error {
+	if p.BodyRef == nil {
+		return nil
+	}
+	if store == nil {
+		return ErrPayloadStoreMissing
+	}
+
+	data, err := store.Get(ctx, p.BodyRef.Key)
+	if err != nil {
+		return err
+	}
+
+	if p.BodyRef.Sealed {
+		if keyring == nil {
+			return ErrPayloadSealed
+		}
+
+		var sealed envelope.Envelope
+		if err := json.Unmarshal(data, &sealed); err != nil {
+			return err
+		}
+		if data, err = openEnvelope(keyring, &sealed, []string{p.BodyRef.Key}, nil); err != nil {
+			return err
+		}
+	}
+
+	p.Body = data
+
+	return nil
+}
+
+// DeleteBody removes an offloaded body from store.
+func (p *TaskPayload) DeleteBody(ctx context.Context, store blobstore.Store) error {
+	if p.BodyRef == nil || store == nil {
+		return nil
+	}
+
+	return store.Delete(ctx, p.BodyRef.Key)
+}
+
+// openEnvelope opens e with the first of the candidate bindings it was
+// sealed with, returning the error of the first candidate otherwise.
+func openEnvelope(keyring *envelope.Keyring, e *envelope.Envelope, candidates ...[]string) ([]byte, error) {
+	var firstErr error
+	for _, bindings := range candidates {
+		plaintext, err := keyring.Open(e, bindings...)
+		if err == nil {
+			return plaintext, nil
+		}
+		if errors.Is(err, envelope.ErrUnknownKey) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
 	}
-	return &p, nil
+	return false
 }
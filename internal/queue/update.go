@@ -0,0 +1,258 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrTaskNotUpdatable is returned by UpdateTask for tasks that are not
+// pending, scheduled or retrying.
+var ErrTaskNotUpdatable = errors.New("only pending, scheduled and retrying tasks can be updated")
+
+// ErrTaskModified is returned by UpdateTask when the task no longer matches
+// the given etag, or kept changing while the update was prepared.
+var ErrTaskModified = errors.New("task was modified concurrently")
+
+// updateAttempts bounds how often an update without etag is prepared again
+// after the task changed underneath it.
+const updateAttempts = 3
+
+// errUpdateConflict reports that the task changed between reading it and
+// running updateTaskScript.
+var errUpdateConflict = errors.New("task changed during update")
+
+// updateTaskScript rewrites the message and processing time of a task, but
+// only if it is still in the state, with the message and score, it was read
+// with. A pending task given a future time is moved to the scheduled set the
+// way asynq schedules tasks.
+//
+// KEYS[1] is the task hash, KEYS[2] the pending list, KEYS[3] the scheduled
+// set and KEYS[4] the retry set. ARGV holds the task ID, the expected state,
+// message and score (ignored for pending tasks), the new message, the new
+// score (empty to keep it) and the current unix time.
+var updateTaskScript = redis.NewScript(`
+local state = redis.call("HGET", KEYS[1], "state")
+if state ~= ARGV[2] or redis.call("HGET", KEYS[1], "msg") ~= ARGV[3] then
+	return 0
+end
+local zset
+if state == "scheduled" then
+	zset = KEYS[3]
+elseif state == "retry" then
+	zset = KEYS[4]
+end
+if zset and tonumber(redis.call("ZSCORE", zset, ARGV[1])) ~= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call("HSET", KEYS[1], "msg", ARGV[5])
+if ARGV[6] ~= "" then
+	if zset then
+		redis.call("ZADD", zset, ARGV[6], ARGV[1])
+	elseif tonumber(ARGV[6]) > tonumber(ARGV[7]) then
+		redis.call("LREM", KEYS[2], 0, ARGV[1])
+		redis.call("ZADD", KEYS[3], ARGV[6], ARGV[1])
+		redis.call("HSET", KEYS[1], "state", "scheduled")
+		redis.call("HDEL", KEYS[1], "pending_since")
+	end
+end
+return 1
+`)
+
+// TaskUpdate describes the changes applied by UpdateTask. Zero fields are
+// left as they are.
+type TaskUpdate struct {
+	ScheduleTime *time.Time
+	// Headers are merged into the task's headers; an empty value removes
+	// the header
+	Headers map[string]string
+	// Body replaces the body when ReplaceBody is set
+	Body        []byte
+	ReplaceBody bool
+	// ETag, when set, must be the current etag of the task
+	ETag string
+}
+
+// TaskETag returns the etag of a task, a digest of its state, processing
+// time and payload. It changes whenever the task is updated, processed or
+// retried.
+func TaskETag(info *asynq.TaskInfo) string {
+	var processAt int64
+	if info.State == asynq.TaskStateScheduled || info.State == asynq.TaskStateRetry {
+		processAt = info.NextProcessAt.Unix()
+	}
+
+	return taskETag(info.State.String(), processAt, info.Payload)
+}
+
+func taskETag(state string, processAt int64, payload []byte) string {
+	h := sha256.New()
+	h.Write([]byte(state))
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(processAt)))
+	h.Write(payload)
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// UpdateTask changes the processing time, headers or body of a pending,
+// scheduled or retrying task in place. The task message is rewritten in
+// asynq's Redis task hash by a script that applies it only if the task did
+// not change since it was read, so a worker never sees a half-applied update.
+func (c *Client) UpdateTask(ctx context.Context, queueName, taskID string, update TaskUpdate) (*asynq.TaskInfo, error) {
+	for attempt := 1; ; attempt++ {
+		err := c.updateTask(ctx, queueName, taskID, update)
+		if errors.Is(err, errUpdateConflict) {
+			// The etag no longer matches either, so only an unconditional
+			// update is tried again
+			if update.ETag != "" || attempt == updateAttempts {
+				return nil, ErrTaskModified
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return c.inspector.GetTaskInfo(queueName, taskID)
+	}
+}
+
+func (c *Client) updateTask(ctx context.Context, queueName, taskID string, update TaskUpdate) error {
+	taskKey := fmt.Sprintf("asynq:{%s}:t:%s", queueName, taskID)
+	keys := []string{
+		taskKey,
+		fmt.Sprintf("asynq:{%s}:pending", queueName),
+		fmt.Sprintf("asynq:{%s}:scheduled", queueName),
+		fmt.Sprintf("asynq:{%s}:retry", queueName),
+	}
+
+	vals, err := c.rdb.HMGet(ctx, taskKey, "state", "msg").Result()
+	if err != nil {
+		return err
+	}
+	state, _ := vals[0].(string)
+	encoded, _ := vals[1].(string)
+	if state == "" || encoded == "" {
+		return asynq.ErrTaskNotFound
+	}
+	msg := []byte(encoded)
+
+	var zsetKey string
+	switch state {
+	case "pending":
+	case "scheduled":
+		zsetKey = keys[2]
+	case "retry":
+		zsetKey = keys[3]
+	default:
+		return ErrTaskNotUpdatable
+	}
+
+	var score int64
+	if zsetKey != "" {
+		zscore, err := c.rdb.ZScore(ctx, zsetKey, taskID).Result()
+		if errors.Is(err, redis.Nil) {
+			// Being moved to pending right now
+			return errUpdateConflict
+		}
+		if err != nil {
+			return err
+		}
+		score = int64(zscore)
+	}
+
+	_, data, err := taskMessageFields(msg)
+	if err != nil {
+		return err
+	}
+	if update.ETag != "" && update.ETag != taskETag(state, score, data) {
+		return errUpdateConflict
+	}
+
+	newMsg := msg
+	var payload *TaskPayload
+	var oldRef *BodyRef
+	offloaded := false
+	if update.Headers != nil || update.ReplaceBody {
+		if payload, err = UnmarshalTaskPayload(data); err != nil {
+			return err
+		}
+		if err := payload.Open(c.keyring, queueName, taskID); err != nil {
+			return err
+		}
+
+		if update.ReplaceBody {
+			oldRef = payload.BodyRef
+			payload.Body = update.Body
+			payload.BodyRef = nil
+			payload.BodyEncoding = ""
+		}
+		if payload.Headers == nil {
+			payload.Headers = map[string]string{}
+		}
+		mergeHeaders(payload.Headers, update.Headers)
+
+		if data, offloaded, err = c.encodePayload(payload, queueName, taskID, true); err != nil {
+			if offloaded {
+				c.discardOffloadedBody(payload)
+			}
+			return err
+		}
+		if newMsg, err = replaceTaskMessagePayload(msg, data); err != nil {
+			if offloaded {
+				c.discardOffloadedBody(payload)
+			}
+			return err
+		}
+	}
+
+	newScore := ""
+	if update.ScheduleTime != nil {
+		newScore = strconv.FormatInt(update.ScheduleTime.Unix(), 10)
+	}
+
+	updated, err := updateTaskScript.Run(ctx, c.rdb, keys,
+		taskID, state, msg, score, newMsg, newScore, time.Now().Unix()).Int()
+	if err == nil && updated == 0 {
+		err = errUpdateConflict
+	}
+	if err != nil {
+		if offloaded {
+			c.discardOffloadedBody(payload)
+		}
+		return err
+	}
+
+	if oldRef != nil && c.store != nil {
+		if err := c.store.Delete(context.Background(), oldRef.Key); err != nil {
+			log.Printf("warning: could not delete replaced body of task %s: %v", taskID, err)
+		}
+	}
+
+	return nil
+}
+
+// mergeHeaders sets headers on dst, replacing existing headers regardless
+// of case. Headers with an empty value are removed.
+func mergeHeaders(dst, headers map[string]string) {
+	for k, v := range headers {
+		for existing := range dst {
+			if strings.EqualFold(existing, k) {
+				delete(dst, existing)
+			}
+		}
+		if v != "" {
+			dst[k] = v
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// leaderKey holds the ID of the scheduler instance allowed to fire schedules.
+const leaderKey = "primind:scheduler:leader"
+
+var renewLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// LeaderElector elects a single leader among scheduler instances using a
+// lease in Redis, so each cron tick is enqueued only once.
+type LeaderElector struct {
+	rdb redis.UniversalClient
+	id  string
+	ttl time.Duration
+}
+
+func NewLeaderElector(rdb redis.UniversalClient, ttl time.Duration) *LeaderElector {
+	hostname, _ := os.Hostname()
+
+	return &LeaderElector{
+		rdb: rdb,
+		id:  fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), uuid.NewString()),
+		ttl: ttl,
+	}
+}
+
+// ID identifies this instance in the lease.
+func (e *LeaderElector) ID() string {
+	return e.id
+}
+
+// Run campaigns for leadership until ctx is done. lead is called each time
+// leadership is acquired; its context is canceled when the lease is lost,
+// and Run waits for it to return before campaigning again.
+func (e *LeaderElector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	interval := e.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		acquired, err := e.rdb.SetNX(ctx, leaderKey, e.id, e.ttl).Result()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			slog.WarnContext(ctx, "leader election failed",
+				slog.String("event", "scheduler.leader.fail"),
+				slog.String("error", err.Error()),
+			)
+		}
+
+		if acquired {
+			e.lead(ctx, ticker, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *LeaderElector) lead(ctx context.Context, ticker *time.Ticker, lead func(ctx context.Context)) {
+	slog.InfoContext(ctx, "acquired scheduler leadership",
+		slog.String("event", "scheduler.leader.acquire"),
+		slog.String("leader_id", e.id),
+	)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leaderCtx)
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+
+		// Release with a fresh context so a shutting down leader hands over promptly
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer releaseCancel()
+		if err := releaseLease.Run(releaseCtx, e.rdb, []string{leaderKey}, e.id).Err(); err != nil {
+			slog.Warn("failed to release scheduler leadership", slog.String("error", err.Error()))
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := renewLease.Run(ctx, e.rdb, []string{leaderKey}, e.id, e.ttl.Milliseconds()).Int()
+		if err != nil || renewed == 0 {
+			attrs := []any{
+				slog.String("event", "scheduler.leader.lost"),
+				slog.String("leader_id", e.id),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			slog.WarnContext(ctx, "lost scheduler leadership", attrs...)
+
+			return
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+// Runner enqueues enabled schedules on their cron expressions.
+//
+// Each run is enqueued with a task ID derived from the schedule ID and the
+// tick time, so a leader that lost its lease without noticing enqueues a
+// conflicting task instead of a second run. The ID conflicts while the
+// task is retained, so runs are retained for at least the fence window,
+// and ticks older than it are skipped rather than enqueued late.
+type Runner struct {
+	store        *Store
+	client       *asynq.Client
+	retryCount   int
+	retention    time.Duration
+	fence        time.Duration
+	syncInterval time.Duration
+	onEnqueue    func(info *asynq.TaskInfo, err error)
+}
+
+// NewRunner enqueues runs with retryCount retries, keeping completed runs
+// for retention or fence, whichever is longer. Schedule changes are picked
+// up every syncInterval, and onEnqueue is called after every enqueue that
+// did not conflict.
+func NewRunner(store *Store, client *asynq.Client, retryCount int, retention, fence, syncInterval time.Duration, onEnqueue func(info *asynq.TaskInfo, err error)) *Runner {
+	return &Runner{
+		store:        store,
+		client:       client,
+		retryCount:   retryCount,
+		retention:    max(retention, fence),
+		fence:        fence,
+		syncInterval: syncInterval,
+		onEnqueue:    onEnqueue,
+	}
+}
+
+// runningSchedule is a schedule being fired by its own goroutine.
+type runningSchedule struct {
+	version string
+	cancel  context.CancelFunc
+}
+
+// Run fires the enabled schedules until ctx is done, and waits for in-flight
+// enqueues before returning.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	running := make(map[string]*runningSchedule)
+
+	defer func() {
+		for _, rs := range running {
+			rs.cancel()
+		}
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(r.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		r.sync(ctx, &wg, running)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync starts a goroutine for each new or changed enabled schedule and stops
+// those of schedules that were paused, changed or deleted.
+func (r *Runner) sync(ctx context.Context, wg *sync.WaitGroup, running map[string]*runningSchedule) {
+	listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	schedules, err := r.store.List(listCtx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to list schedules",
+				slog.String("event", "schedule.sync.fail"),
+				slog.String("error", err.Error()),
+			)
+		}
+
+		return
+	}
+
+	wanted := make(map[string]*Schedule, len(schedules))
+	for _, sched := range schedules {
+		if !sched.Paused {
+			wanted[sched.ID] = sched
+		}
+	}
+
+	for id, rs := range running {
+		if sched, ok := wanted[id]; !ok || scheduleVersion(sched) != rs.version {
+			rs.cancel()
+			delete(running, id)
+		}
+	}
+
+	for id, sched := range wanted {
+		if _, ok := running[id]; ok {
+			continue
+		}
+
+		spec, err := cron.ParseStandard(sched.Cronspec())
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to parse schedule",
+				slog.String("event", "schedule.sync.fail"),
+				slog.String("schedule_id", id),
+				slog.String("error", err.Error()),
+			)
+
+			continue
+		}
+
+		fireCtx, cancel := context.WithCancel(ctx)
+		running[id] = &runningSchedule{version: scheduleVersion(sched), cancel: cancel}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.fire(fireCtx, sched, spec)
+		}()
+	}
+}
+
+// scheduleVersion changes whenever the runs of a schedule would change.
+func scheduleVersion(sched *Schedule) string {
+	return sched.Cronspec() + "\x00" + sched.Queue + "\x00" + string(sched.Payload)
+}
+
+// fire enqueues a run of sched at each tick of spec until ctx is done.
+func (r *Runner) fire(ctx context.Context, sched *Schedule, spec cron.Schedule) {
+	next := spec.Next(time.Now())
+
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// The timer and a lost lease can race; never fire for a past term
+		if ctx.Err() != nil {
+			return
+		}
+
+		if late := time.Since(next); late > r.fence {
+			slog.WarnContext(ctx, "skipped late schedule run",
+				slog.String("event", "schedule.enqueue.skip"),
+				slog.String("schedule_id", sched.ID),
+				slog.Time("tick", next),
+				slog.Duration("late", late),
+			)
+		} else {
+			r.enqueue(ctx, sched, next)
+		}
+
+		next = spec.Next(time.Now())
+	}
+}
+
+func (r *Runner) enqueue(ctx context.Context, sched *Schedule, tick time.Time) {
+	taskID := sched.ID + ":" + strconv.FormatInt(tick.Unix(), 10)
+
+	info, err := r.client.EnqueueContext(ctx,
+		asynq.NewTask(queue.TaskTypeHTTPForward, sched.Payload),
+		asynq.Queue(sched.Queue),
+		asynq.MaxRetry(r.retryCount),
+		asynq.TaskID(taskID),
+		asynq.Retention(r.retention),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		slog.InfoContext(ctx, "schedule run already enqueued",
+			slog.String("event", "schedule.enqueue.duplicate"),
+			slog.String("schedule_id", sched.ID),
+			slog.String("task_id", taskID),
+		)
+
+		return
+	}
+
+	r.onEnqueue(info, err)
+}
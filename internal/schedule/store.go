@@ -0,0 +1,194 @@
+// Package schedule manages recurring tasks enqueued on cron schedules.
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	StateEnabled = "ENABLED"
+	StatePaused  = "PAUSED"
+)
+
+// schedulesKey is the Redis hash holding schedules by ID.
+const schedulesKey = "primind:schedules"
+
+// maxUpdateAttempts bounds the optimistic transaction retries of SetPaused;
+// all schedules share one hash, so any concurrent change conflicts.
+const maxUpdateAttempts = 10
+
+var (
+	ErrNotFound      = errors.New("schedule not found")
+	ErrAlreadyExists = errors.New("schedule already exists")
+)
+
+// Schedule is a cron expression together with the task it enqueues.
+type Schedule struct {
+	ID       string `json:"id"`
+	Cron     string `json:"cron"`
+	TimeZone string `json:"time_zone"`
+	Queue    string `json:"queue"`
+	// Payload is the encoded queue.TaskPayload enqueued on every run
+	Payload   []byte    `json:"payload"`
+	Paused    bool      `json:"paused"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Cronspec returns the cron expression with its time zone, as understood by
+// cron.ParseStandard.
+func (s *Schedule) Cronspec() string {
+	return fmt.Sprintf("CRON_TZ=%s %s", s.TimeZone, s.Cron)
+}
+
+func (s *Schedule) State() string {
+	if s.Paused {
+		return StatePaused
+	}
+
+	return StateEnabled
+}
+
+// ValidateCron checks a five-field cron expression and an IANA time zone.
+func ValidateCron(expr, timeZone string) error {
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+	}
+
+	if _, err := cron.ParseStandard(expr); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+
+	return nil
+}
+
+// Store persists schedules in Redis.
+type Store struct {
+	rdb redis.UniversalClient
+}
+
+func NewStore(rdb redis.UniversalClient) *Store {
+	return &Store{rdb: rdb}
+}
+
+func (s *Store) Create(ctx context.Context, sched *Schedule) error {
+	data, err := json.Marshal(sched)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.rdb.HSetNX(ctx, schedulesKey, sched.ID, data).Result()
+	if err != nil {
+		return err
+	}
+	if !created {
+		return fmt.Errorf("%w: %s", ErrAlreadyExists, sched.ID)
+	}
+
+	return nil
+}
+
+func (s *Store) Get(ctx context.Context, id string) (*Schedule, error) {
+	return s.get(ctx, s.rdb, id)
+}
+
+func (s *Store) get(ctx context.Context, c redis.Cmdable, id string) (*Schedule, error) {
+	data, err := c.HGet(ctx, schedulesKey, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sched Schedule
+	if err := json.Unmarshal(data, &sched); err != nil {
+		return nil, err
+	}
+
+	return &sched, nil
+}
+
+// List returns all schedules ordered by creation time.
+func (s *Store) List(ctx context.Context) ([]*Schedule, error) {
+	entries, err := s.rdb.HGetAll(ctx, schedulesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]*Schedule, 0, len(entries))
+	for _, data := range entries {
+		var sched Schedule
+		if err := json.Unmarshal([]byte(data), &sched); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, &sched)
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+
+	return schedules, nil
+}
+
+// SetPaused pauses or resumes a schedule in an optimistic transaction,
+// retrying when another change got in between.
+func (s *Store) SetPaused(ctx context.Context, id string, paused bool) (*Schedule, error) {
+	for range maxUpdateAttempts {
+		var sched *Schedule
+
+		err := s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			current, err := s.get(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+
+			current.Paused = paused
+			current.UpdatedAt = time.Now()
+
+			data, err := json.Marshal(current)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.HSet(ctx, schedulesKey, id, data)
+				return nil
+			})
+			sched = current
+
+			return err
+		}, schedulesKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return sched, nil
+	}
+
+	return nil, fmt.Errorf("update schedule %s: too many concurrent updates", id)
+}
+
+func (s *Store) Delete(ctx context.Context, id string) error {
+	deleted, err := s.rdb.HDel(ctx, schedulesKey, id).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	return nil
+}
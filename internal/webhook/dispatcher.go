@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	pjson "github.com/KasumiMercury/primind-tasks/internal/proto"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+const (
+	// group is the consumer group shared by the dispatchers of all workers,
+	// so each event is handled once
+	group = "primind-webhooks"
+	// taskIDPrefix marks notification tasks, whose own events are not
+	// notified to avoid loops
+	taskIDPrefix = "webhook:"
+
+	readCount = 100
+	readBlock = 5 * time.Second
+	// claimIdle is how long an event stays unacknowledged before another
+	// dispatcher takes it over
+	claimIdle     = time.Minute
+	claimInterval = 30 * time.Second
+	retryDelay    = time.Second
+)
+
+// Dispatcher reads lifecycle events from the event stream and enqueues a
+// notification task for every matching subscription. The worker delivers
+// the notifications like any other task, with its retries and destination
+// policy.
+type Dispatcher struct {
+	stream   *events.Stream
+	client   *queue.Client
+	subs     []Subscription
+	queues   []string
+	consumer string
+}
+
+// NewDispatcher creates a dispatcher enqueueing notifications into the
+// default queue of client.
+func NewDispatcher(stream *events.Stream, client *queue.Client, subs []Subscription) *Dispatcher {
+	var queues []string
+	for _, sub := range subs {
+		if !slices.Contains(queues, sub.Queue) {
+			queues = append(queues, sub.Queue)
+		}
+	}
+
+	return &Dispatcher{
+		stream:   stream,
+		client:   client,
+		subs:     subs,
+		queues:   queues,
+		consumer: consumerName(),
+	}
+}
+
+// consumerName identifies this process in the consumer group as
+// hostname:pid, like the worker instance in the attempt history.
+func consumerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// Run dispatches events until ctx is cancelled. An event is acknowledged
+// once its notifications are enqueued; events left unacknowledged by a
+// failure or crash are claimed again after claimIdle.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ready := false
+	var lastClaim time.Time
+
+	for ctx.Err() == nil {
+		if !ready {
+			if err := d.ensureGroups(ctx); err != nil {
+				d.logReadFailure(ctx, err)
+				d.wait(ctx)
+				continue
+			}
+			ready = true
+		}
+
+		if time.Since(lastClaim) >= claimInterval {
+			lastClaim = time.Now()
+			for _, queueName := range d.queues {
+				evs, err := d.stream.Claim(ctx, queueName, group, d.consumer, claimIdle, readCount)
+				if err != nil {
+					d.logReadFailure(ctx, err)
+					continue
+				}
+				d.handle(ctx, evs)
+			}
+		}
+
+		evs, err := d.stream.ReadGroup(ctx, group, d.consumer, d.queues, readCount, readBlock)
+		if err != nil {
+			// The group is gone if the stream was deleted
+			ready = false
+			d.logReadFailure(ctx, err)
+			d.wait(ctx)
+			continue
+		}
+		d.handle(ctx, evs)
+	}
+}
+
+func (d *Dispatcher) ensureGroups(ctx context.Context) error {
+	for _, queueName := range d.queues {
+		if err := d.stream.EnsureGroup(ctx, queueName, group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) handle(ctx context.Context, evs []events.Event) {
+	for _, ev := range evs {
+		done := true
+
+		// Trimmed entries come back without fields
+		if ev.Type != "" && !strings.HasPrefix(ev.TaskID, taskIDPrefix) {
+			for i := range d.subs {
+				sub := &d.subs[i]
+				if !sub.matches(ev) {
+					continue
+				}
+				if err := d.notify(sub, ev); err != nil {
+					done = false
+					slog.ErrorContext(ctx, "failed to enqueue webhook notification",
+						slog.String("event", "webhook.enqueue.fail"),
+						slog.String("webhook.subscription", sub.Name),
+						slog.String("webhook.event_id", ev.ID),
+						slog.String("task_id", ev.TaskID),
+						slog.String("error", err.Error()),
+					)
+					continue
+				}
+				slog.InfoContext(ctx, "enqueued webhook notification",
+					slog.String("event", "webhook.enqueue"),
+					slog.String("webhook.subscription", sub.Name),
+					slog.String("webhook.event_id", ev.ID),
+					slog.String("webhook.event_type", ev.Type),
+					slog.String("task_id", ev.TaskID),
+				)
+			}
+		}
+
+		if !done {
+			continue
+		}
+		if err := d.stream.Ack(ctx, ev.Queue, group, ev.ID); err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "failed to acknowledge event",
+				slog.String("event", "webhook.ack.fail"),
+				slog.String("webhook.event_id", ev.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// notify enqueues the notification of ev for sub. The task ID is derived
+// from the event, so an event handled twice is notified once. The worker
+// signs the request when sending it; the task only carries a dispatch token
+// proving it was enqueued here.
+func (d *Dispatcher) notify(sub *Subscription, ev events.Event) error {
+	body, err := pjson.Marshal(ev.ToProto())
+	if err != nil {
+		return err
+	}
+
+	taskID := fmt.Sprintf("%s%s:%s", taskIDPrefix, sub.Name, ev.ID)
+	payload := queue.NewTaskPayload(body, map[string]string{
+		"Content-Type":      "application/json",
+		HeaderEventID:       ev.ID,
+		HeaderEventType:     ev.Type,
+		HeaderSubscription:  sub.Name,
+		headerDispatchToken: dispatchToken([]byte(sub.Secret), taskID, sub.URL, body),
+	})
+	payload.URL = sub.URL
+
+	var opts []queue.EnqueueOption
+	if sub.MaxRetries != nil {
+		opts = append(opts, queue.WithMaxRetry(*sub.MaxRetries))
+	}
+
+	_, err = d.client.EnqueueTask(payload, nil, taskID, opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+
+	return err
+}
+
+func (d *Dispatcher) logReadFailure(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	slog.ErrorContext(ctx, "failed to read events for webhooks",
+		slog.String("event", "webhook.read.fail"),
+		slog.String("error", err.Error()),
+	)
+}
+
+func (d *Dispatcher) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(retryDelay):
+	}
+}
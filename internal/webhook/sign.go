@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of notification requests.
+const (
+	HeaderEventID      = "X-Primind-Event-Id"
+	HeaderEventType    = "X-Primind-Event-Type"
+	HeaderSubscription = "X-Primind-Subscription"
+	HeaderTimestamp    = "X-Primind-Timestamp"
+	HeaderSignature    = "X-Primind-Signature"
+)
+
+// headerDispatchToken carries the proof that a notification task was
+// enqueued by the dispatcher. It is checked and removed by the worker and
+// never sent.
+const headerDispatchToken = "X-Primind-Webhook-Token"
+
+// ErrInvalidNotification is returned by Signer.SignRequest for notification
+// tasks it cannot sign.
+var ErrInvalidNotification = errors.New("invalid webhook notification")
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of the timestamp, a dot and the body.
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// dispatchToken binds a notification task to its ID, target and body, so
+// the worker only signs what the dispatcher enqueued.
+func dispatchToken(secret []byte, taskID, target string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	for _, part := range [][]byte{[]byte("dispatch"), []byte(taskID), []byte(target)} {
+		mac.Write(part)
+		mac.Write([]byte{0})
+	}
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsNotification reports whether taskID names a notification task.
+func IsNotification(taskID string) bool {
+	return strings.HasPrefix(taskID, taskIDPrefix)
+}
+
+// Signer signs notification requests when the worker sends them, so every
+// delivery, retries included, carries a fresh timestamp. A nil Signer signs
+// nothing.
+type Signer struct {
+	secrets map[string][]byte
+	now     func() time.Time
+}
+
+func NewSigner(subs []Subscription) *Signer {
+	secrets := make(map[string][]byte, len(subs))
+	for _, sub := range subs {
+		secrets[sub.Name] = []byte(sub.Secret)
+	}
+
+	return &Signer{secrets: secrets, now: time.Now}
+}
+
+// SignRequest sets the timestamp and signature headers of a notification
+// request to target with body. Requests of other tasks are left unchanged.
+// Notifications that were not enqueued by the dispatcher, or whose
+// subscription is unknown, are rejected with ErrInvalidNotification.
+func (s *Signer) SignRequest(taskID, target string, req *http.Request, body []byte) error {
+	token := req.Header.Get(headerDispatchToken)
+	req.Header.Del(headerDispatchToken)
+	if token == "" || !IsNotification(taskID) {
+		return nil
+	}
+
+	name := req.Header.Get(HeaderSubscription)
+	if s == nil {
+		return fmt.Errorf("%w: no subscriptions are loaded to sign %q", ErrInvalidNotification, name)
+	}
+	secret, ok := s.secrets[name]
+	if !ok {
+		return fmt.Errorf("%w: unknown subscription %q", ErrInvalidNotification, name)
+	}
+	if !hmac.Equal([]byte(token), []byte(dispatchToken(secret, taskID, target, body))) {
+		return fmt.Errorf("%w: dispatch token mismatch", ErrInvalidNotification)
+	}
+
+	timestamp := s.now().Unix()
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	return nil
+}
@@ -0,0 +1,89 @@
+// Package webhook notifies subscribers of task lifecycle events through
+// signed HTTP requests, delivered as tasks by the worker.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+)
+
+// namePattern restricts subscription names, which are part of task IDs.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,100}$`)
+
+// Subscription sends the selected events of a queue to URL.
+type Subscription struct {
+	Name  string `json:"name"`
+	Queue string `json:"queue"`
+	URL   string `json:"url"`
+	// Secret is the HMAC-SHA256 key for the request signature
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	// MaxRetries overrides RETRY_COUNT for the notification tasks
+	MaxRetries *int `json:"maxRetries,omitempty"`
+}
+
+// LoadSubscriptions reads a JSON array of Subscription from path.
+func LoadSubscriptions(filePath string) ([]Subscription, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []Subscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("parse subscriptions file: %w", err)
+	}
+
+	if err := Validate(subs); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func Validate(subs []Subscription) error {
+	names := make(map[string]bool, len(subs))
+	for i, sub := range subs {
+		if !namePattern.MatchString(sub.Name) {
+			return fmt.Errorf("subscription %d: invalid name %q: use up to 100 letters, digits, '-' or '_'", i, sub.Name)
+		}
+		if names[sub.Name] {
+			return fmt.Errorf("subscription %q: duplicate name", sub.Name)
+		}
+		names[sub.Name] = true
+
+		if sub.Queue == "" {
+			return fmt.Errorf("subscription %q: queue is required", sub.Name)
+		}
+		u, err := url.Parse(sub.URL)
+		if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("subscription %q: invalid url %q: an absolute http(s) URL is required", sub.Name, sub.URL)
+		}
+		if sub.Secret == "" {
+			return fmt.Errorf("subscription %q: secret is required", sub.Name)
+		}
+		if len(sub.Events) == 0 {
+			return fmt.Errorf("subscription %q: events is required", sub.Name)
+		}
+		for _, typ := range sub.Events {
+			if !slices.Contains(events.Types, typ) {
+				return fmt.Errorf("subscription %q: unknown event %q", sub.Name, typ)
+			}
+		}
+		if sub.MaxRetries != nil && *sub.MaxRetries < 0 {
+			return fmt.Errorf("subscription %q: maxRetries must not be negative", sub.Name)
+		}
+	}
+
+	return nil
+}
+
+func (sub *Subscription) matches(ev events.Event) bool {
+	return ev.Queue == sub.Queue && slices.Contains(sub.Events, ev.Type)
+}
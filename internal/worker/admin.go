@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"connectrpc.com/grpchealth"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/KasumiMercury/primind-tasks/internal/health"
+)
+
+const serverStatusActive = "active"
+
+// AdminServer is a small HTTP/h2c listener exposing health, readiness and
+// operational endpoints of the worker.
+type AdminServer struct {
+	server         *Server
+	healthChecker  *health.Checker
+	metricsHandler http.Handler
+	port           int
+	httpServer     *http.Server
+}
+
+// AdminStatus is the response of the admin status endpoint.
+type AdminStatus struct {
+	ServerID    string         `json:"server_id"`
+	Host        string         `json:"host"`
+	PID         int            `json:"pid"`
+	Status      string         `json:"status"`
+	Started     time.Time      `json:"started"`
+	Concurrency int            `json:"concurrency"`
+	Queues      map[string]int `json:"queues"`
+	ActiveTasks []ActiveTask   `json:"active_tasks"`
+}
+
+// ActiveTask describes a task currently being processed by this worker.
+type ActiveTask struct {
+	TaskID   string    `json:"task_id"`
+	TaskType string    `json:"task_type"`
+	Queue    string    `json:"queue"`
+	Started  time.Time `json:"started"`
+	Deadline time.Time `json:"deadline"`
+}
+
+func NewAdminServer(port int, server *Server, version string, metricsHandler http.Handler) *AdminServer {
+	checker := health.NewChecker(server, version)
+	checker.AddCheck("asynq", func(_ context.Context) error {
+		info, err := server.Info()
+		if err != nil {
+			return err
+		}
+		if info.Status != serverStatusActive {
+			return fmt.Errorf("worker server status is %q", info.Status)
+		}
+
+		return nil
+	})
+
+	return &AdminServer{
+		server:         server,
+		healthChecker:  checker,
+		metricsHandler: metricsHandler,
+		port:           port,
+	}
+}
+
+func (s *AdminServer) Router() http.Handler {
+	r := chi.NewRouter()
+
+	// Health check endpoints
+	r.Get("/health/live", s.healthChecker.LiveHandler)
+	r.Get("/health/ready", s.healthChecker.ReadyHandler)
+	r.Get("/health", s.healthChecker.ReadyHandler)
+
+	// Worker state
+	r.Get("/admin/status", s.StatusHandler)
+
+	// Prometheus scrape endpoint
+	if s.metricsHandler != nil {
+		r.Handle("/metrics", s.metricsHandler)
+	}
+
+	// gRPC Health Checking Protocol (grpc.health.v1.Health/Check)
+	grpcHealthChecker := health.NewGRPCChecker(s.healthChecker)
+	grpcHealthPath, grpcHealthHandler := grpchealth.NewHandler(grpcHealthChecker)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, grpcHealthPath) {
+			grpcHealthHandler.ServeHTTP(w, req)
+			return
+		}
+		r.ServeHTTP(w, req)
+	})
+}
+
+// StatusHandler reports concurrency, consumed queues and active tasks of this worker.
+func (s *AdminServer) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	info, err := s.server.Info()
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, ErrServerNotRegistered) {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); err != nil {
+			slog.Warn("failed to write response", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	status := AdminStatus{
+		ServerID:    info.ID,
+		Host:        info.Host,
+		PID:         info.PID,
+		Status:      info.Status,
+		Started:     info.Started,
+		Concurrency: info.Concurrency,
+		Queues:      info.Queues,
+		ActiveTasks: make([]ActiveTask, 0, len(info.ActiveWorkers)),
+	}
+	for _, worker := range info.ActiveWorkers {
+		status.ActiveTasks = append(status.ActiveTasks, ActiveTask{
+			TaskID:   worker.TaskID,
+			TaskType: worker.TaskType,
+			Queue:    worker.Queue,
+			Started:  worker.Started,
+			Deadline: worker.Deadline,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		slog.Warn("failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+func (s *AdminServer) ListenAndServe() error {
+	h2s := &http2.Server{}
+	s.httpServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.port),
+		Handler: h2c.NewHandler(s.Router(), h2s),
+	}
+
+	return s.httpServer.ListenAndServe()
+}
+
+func (s *AdminServer) Shutdown(ctx context.Context) error {
+	if s.httpServer != nil {
+		return s.httpServer.Shutdown(ctx)
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// blockedPrefixes are denied for per-task URLs unless explicitly allowed, in
+// addition to loopback, link-local, multicast and unspecified addresses.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("fd00:ec2::254/128"),  // AWS IMDS over IPv6
+	netip.MustParsePrefix("100.100.100.200/32"), // Alibaba Cloud metadata
+}
+
+// sharedPrefixes are the shared address space (RFC 6598), blocked with the
+// private ranges for per-task URLs.
+var sharedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// DestinationError reports a dispatch blocked by the DestinationPolicy.
+type DestinationError struct {
+	Host   string
+	IP     string
+	Reason string
+}
+
+func (e *DestinationError) Error() string {
+	if e.IP != "" {
+		return fmt.Sprintf("destination %s (%s) is not allowed: %s", e.Host, e.IP, e.Reason)
+	}
+
+	return fmt.Sprintf("destination %s is not allowed: %s", e.Host, e.Reason)
+}
+
+// DestinationPolicy restricts where the worker may send requests. Hosts are
+// checked before dialing; IP addresses are checked on the resolved address at
+// connect time, so DNS rebinding cannot bypass the policy.
+//
+// The configured lists apply to every destination. The built-in blocks only
+// apply to URLs chosen per task, so TARGET_ENDPOINT may point at a sidecar
+// on localhost or a private address without further configuration.
+type DestinationPolicy struct {
+	// allowHosts, when non-empty, is the exhaustive list of permitted hosts
+	allowHosts []string
+	denyHosts  []string
+	// allowPrefixes, when non-empty, is the exhaustive list of permitted
+	// addresses and overrides the built-in blocks
+	allowPrefixes []netip.Prefix
+	denyPrefixes  []netip.Prefix
+	// builtinBlocks blocks loopback, link-local, metadata, private and
+	// shared addresses unless allowPrefixes is set
+	builtinBlocks bool
+}
+
+// NewDestinationPolicy parses host patterns ("example.com", "*.example.com")
+// and CIDRs or single IP addresses.
+func NewDestinationPolicy(allowHosts, denyHosts, allowCIDRs, denyCIDRs []string) (*DestinationPolicy, error) {
+	allowPrefixes, err := parsePrefixes(allowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	denyPrefixes, err := parsePrefixes(denyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DestinationPolicy{
+		allowHosts:    normalizeHosts(allowHosts),
+		denyHosts:     normalizeHosts(denyHosts),
+		allowPrefixes: allowPrefixes,
+		denyPrefixes:  denyPrefixes,
+	}, nil
+}
+
+// DefaultDestinationPolicy restricts nothing but per-task URLs, which get
+// the built-in blocks.
+func DefaultDestinationPolicy() *DestinationPolicy {
+	return &DestinationPolicy{}
+}
+
+// forTaskURLs returns the policy for URLs chosen per task. Whoever can
+// enqueue a task picks those, so the built-in blocks apply unless
+// DESTINATION_ALLOW_CIDRS lists the permitted addresses.
+func (p *DestinationPolicy) forTaskURLs() *DestinationPolicy {
+	q := *p
+	q.builtinBlocks = true
+
+	return &q
+}
+
+// CheckHost validates the host name of a destination.
+func (p *DestinationPolicy) CheckHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, pattern := range p.denyHosts {
+		if matchHost(pattern, host) {
+			return &DestinationError{Host: host, Reason: "host is denylisted"}
+		}
+	}
+
+	if len(p.allowHosts) == 0 {
+		return nil
+	}
+
+	for _, pattern := range p.allowHosts {
+		if matchHost(pattern, host) {
+			return nil
+		}
+	}
+
+	return &DestinationError{Host: host, Reason: "host is not allowlisted"}
+}
+
+// CheckIP validates a resolved destination address.
+func (p *DestinationPolicy) CheckIP(host string, ip netip.Addr) error {
+	ip = ip.Unmap()
+
+	for _, prefix := range p.denyPrefixes {
+		if prefix.Contains(ip) {
+			return &DestinationError{Host: host, IP: ip.String(), Reason: "address is denylisted"}
+		}
+	}
+
+	if len(p.allowPrefixes) > 0 {
+		for _, prefix := range p.allowPrefixes {
+			if prefix.Contains(ip) {
+				return nil
+			}
+		}
+
+		return &DestinationError{Host: host, IP: ip.String(), Reason: "address is not allowlisted"}
+	}
+
+	if !p.builtinBlocks {
+		return nil
+	}
+
+	if reason := blockedReason(ip); reason != "" {
+		return &DestinationError{Host: host, IP: ip.String(), Reason: reason}
+	}
+
+	if reason := privateReason(ip); reason != "" {
+		return &DestinationError{Host: host, IP: ip.String(), Reason: reason}
+	}
+
+	return nil
+}
+
+// Transport returns an HTTP transport enforcing the policy. Proxies are
+// disabled so the checked address is the one actually connected to.
+func (p *DestinationPolicy) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = p.dialContext
+
+	return transport
+}
+
+func (p *DestinationPolicy) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckHost(host); err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, resolved string, _ syscall.RawConn) error {
+			ipStr, _, err := net.SplitHostPort(resolved)
+			if err != nil {
+				return err
+			}
+
+			ip, err := netip.ParseAddr(ipStr)
+			if err != nil {
+				return err
+			}
+
+			return p.CheckIP(host, ip)
+		},
+	}
+
+	return dialer.DialContext(ctx, network, address)
+}
+
+func blockedReason(ip netip.Addr) string {
+	switch {
+	case ip.IsLoopback():
+		return "loopback address"
+	case ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast():
+		return "link-local address (includes cloud metadata endpoints)"
+	case ip.IsUnspecified():
+		return "unspecified address"
+	case ip.IsMulticast():
+		return "multicast address"
+	}
+
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return "cloud metadata address"
+		}
+	}
+
+	return ""
+}
+
+func privateReason(ip netip.Addr) string {
+	if ip.IsPrivate() {
+		return "private address (task URLs need DESTINATION_ALLOW_CIDRS to reach it)"
+	}
+
+	for _, prefix := range sharedPrefixes {
+		if prefix.Contains(ip) {
+			return "shared address (task URLs need DESTINATION_ALLOW_CIDRS to reach it)"
+		}
+	}
+
+	return ""
+}
+
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", v, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", v, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+func normalizeHosts(hosts []string) []string {
+	out := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		out = append(out, strings.ToLower(strings.TrimSuffix(h, ".")))
+	}
+
+	return out
+}
+
+func matchHost(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+
+	return pattern == host
+}
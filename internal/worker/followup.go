@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
+	"github.com/KasumiMercury/primind-tasks/internal/observability/tracing"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+// Headers describing the previous task, added to follow-up tasks.
+const (
+	HeaderPreviousTaskID = "X-Primind-Previous-Task-Id"
+	HeaderPreviousStatus = "X-Primind-Previous-Status"
+	HeaderPreviousError  = "X-Primind-Previous-Error"
+)
+
+// enqueueFollowUp enqueues the onSuccess task after a successful attempt, or
+// the onFailure task after the final failed attempt. A follow-up without a
+// body of its own receives the previous response body.
+//
+// The follow-up task ID is derived from the task ID, so a task processed
+// twice (e.g. after a worker crash) enqueues its follow-up only once.
+func (h *HTTPForwardHandler) enqueueFollowUp(ctx context.Context, payload *queue.TaskPayload, queueName, taskID string, httpStatus int, respBody []byte, taskErr error) {
+	if payload == nil {
+		return
+	}
+
+	var (
+		encoded []byte
+		kind    string
+	)
+	switch {
+	case taskErr == nil:
+		encoded, kind = payload.OnSuccess, "on-success"
+	case isFinalAttempt(ctx, taskErr):
+		encoded, kind = payload.OnFailure, "on-failure"
+	default:
+		return
+	}
+	if len(encoded) == 0 {
+		return
+	}
+
+	logFail := func(reason string, err error) {
+		slog.ErrorContext(ctx, "failed to enqueue follow-up task",
+			slog.String("event", "callback.enqueue.fail"),
+			slog.String("job.id", taskID),
+			slog.String("callback", kind),
+			slog.String("reason", reason),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	if h.followUps == nil {
+		logFail("not_configured", errors.New("no queue client configured for follow-up tasks"))
+		return
+	}
+
+	followUp, err := queue.UnmarshalTaskPayload(encoded)
+	if err != nil {
+		logFail("unmarshal_error", err)
+		return
+	}
+	// Follow-ups are sealed as templates for the queue they are enqueued to
+	if err := followUp.Open(h.keyring, queueName, ""); err != nil {
+		logFail("decrypt_error", err)
+		return
+	}
+
+	if len(followUp.Body) == 0 && followUp.BodyRef == nil {
+		followUp.Body = respBody
+	}
+	followUp.CreatedAt = time.Now()
+	followUp.Headers[HeaderPreviousTaskID] = taskID
+	followUp.Headers[HeaderPreviousStatus] = strconv.Itoa(httpStatus)
+	if taskErr != nil {
+		followUp.Headers[HeaderPreviousError] = taskErr.Error()
+	}
+	tracing.InjectToMap(ctx, followUp.Headers)
+	followUp.Headers["x-request-id"] = logging.RequestIDFromContext(ctx)
+
+	followUpID := taskID + ":" + kind
+	info, err := h.followUps.EnqueueTaskWithQueue(followUp, nil, queueName, followUpID)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return
+	}
+	if err != nil {
+		logFail("enqueue_error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "enqueued follow-up task",
+		slog.String("event", "callback.enqueue"),
+		slog.String("job.id", taskID),
+		slog.String("callback", kind),
+		slog.String("callback.task_id", info.ID),
+	)
+}
+
+// isFinalAttempt reports whether a failed attempt will not be retried.
+func isFinalAttempt(ctx context.Context, err error) bool {
+	if errors.Is(err, asynq.SkipRetry) {
+		return true
+	}
+
+	retried, ok := asynq.GetRetryCount(ctx)
+	if !ok {
+		return false
+	}
+	maxRetry, ok := asynq.GetMaxRetry(ctx)
+	if !ok {
+		return false
+	}
+
+	return retried >= maxRetry
+}
@@ -3,6 +3,7 @@ package worker
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -13,27 +14,176 @@ import (
 	"github.com/hibiken/asynq"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/KasumiMercury/primind-tasks/internal/attempts"
+	"github.com/KasumiMercury/primind-tasks/internal/blobstore"
+	"github.com/KasumiMercury/primind-tasks/internal/envelope"
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+	"github.com/KasumiMercury/primind-tasks/internal/labels"
 	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
 	"github.com/KasumiMercury/primind-tasks/internal/observability/tracing"
 	"github.com/KasumiMercury/primind-tasks/internal/queue"
+	"github.com/KasumiMercury/primind-tasks/internal/webhook"
+	"github.com/KasumiMercury/primind-tasks/internal/workflow"
 )
 
 type HTTPForwardHandler struct {
 	targetEndpoint string
 	httpClient     *http.Client
+	// urlClient sends tasks carrying their own URL, under the stricter
+	// destination policy for per-task URLs
+	urlClient    *http.Client
+	linkMode     tracing.TaskLinkMode
+	keyring      *envelope.Keyring
+	destinations *DestinationPolicy
+	store        blobstore.Store
+	// followUps enqueues onSuccess/onFailure tasks
+	followUps *queue.Client
+	workflows *workflow.Engine
+	// recordHeaders and recordBodyLimit select what of the response is
+	// kept in the task result
+	recordHeaders   []string
+	recordBodyLimit int
+	attempts        *attempts.Store
+	// instance identifies this worker in the attempt history
+	instance string
+	history  *history.Sink
+	events   *events.Stream
+	labels   *labels.Index
+	// webhooks signs notification requests; without it notifications fail
+	webhooks *webhook.Signer
+	// forwardCompressed sends compressed bodies with Content-Encoding
+	// instead of decompressing them
+	forwardCompressed bool
 }
 
-func NewHTTPForwardHandler(targetEndpoint string, timeout time.Duration) *HTTPForwardHandler {
-	return &HTTPForwardHandler{
+// HandlerOption configures optional behavior of the HTTPForwardHandler.
+type HandlerOption func(*HTTPForwardHandler)
+
+// WithTraceLinkMode sets how the task.process span relates to the enqueue span.
+func WithTraceLinkMode(mode tracing.TaskLinkMode) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.linkMode = mode
+	}
+}
+
+// WithPayloadKeyring decrypts sealed payloads with keyring.
+func WithPayloadKeyring(keyring *envelope.Keyring) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.keyring = keyring
+	}
+}
+
+// WithDestinationPolicy restricts the addresses tasks may be dispatched to.
+func WithDestinationPolicy(policy *DestinationPolicy) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.destinations = policy
+	}
+}
+
+// WithPayloadStore fetches offloaded bodies from store and deletes them once
+// the task has been delivered.
+func WithPayloadStore(store blobstore.Store) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.store = store
+	}
+}
+
+// WithForwardCompressed forwards compressed bodies as is, announcing the
+// compression with a Content-Encoding header.
+func WithForwardCompressed(enabled bool) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.forwardCompressed = enabled
+	}
+}
+
+// WithFollowUpClient enqueues the onSuccess/onFailure tasks of processed
+// tasks through client.
+func WithFollowUpClient(client *queue.Client) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.followUps = client
+	}
+}
+
+// WithWorkflows reports the outcome of workflow node tasks to engine.
+func WithWorkflows(engine *workflow.Engine) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.workflows = engine
+	}
+}
+
+// WithResponseRecording keeps the named response headers and up to
+// bodyLimit bytes of the response body in the task result.
+func WithResponseRecording(headers []string, bodyLimit int) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.recordHeaders = headers
+		h.recordBodyLimit = max(bodyLimit, 0)
+	}
+}
+
+// WithAttemptHistory appends every dispatch attempt to store.
+func WithAttemptHistory(store *attempts.Store) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.attempts = store
+	}
+}
+
+// WithHistory records dispatches and task outcomes in sink.
+func WithHistory(sink *history.Sink) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.history = sink
+	}
+}
+
+// WithEvents publishes task starts and outcomes to stream.
+func WithEvents(stream *events.Stream) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.events = stream
+	}
+}
+
+// WithWebhookSigner signs webhook notifications when they are sent.
+func WithWebhookSigner(signer *webhook.Signer) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.webhooks = signer
+	}
+}
+
+// WithLabelIndex removes delivered tasks from index, so label searches and
+// batch operations only see unfinished tasks.
+func WithLabelIndex(index *labels.Index) HandlerOption {
+	return func(h *HTTPForwardHandler) {
+		h.labels = index
+	}
+}
+
+func NewHTTPForwardHandler(targetEndpoint string, timeout time.Duration, opts ...HandlerOption) *HTTPForwardHandler {
+	h := &HTTPForwardHandler{
 		targetEndpoint: targetEndpoint,
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
+		urlClient: &http.Client{
+			Timeout: timeout,
+		},
+		linkMode:     tracing.TaskLinkParent,
+		destinations: DefaultDestinationPolicy(),
+		instance:     workerInstance(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	h.httpClient.Transport = h.destinations.Transport()
+	h.urlClient.Transport = h.destinations.forTaskURLs().Transport()
+
+	return h
 }
 
-func (h *HTTPForwardHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+func (h *HTTPForwardHandler) ProcessTask(ctx context.Context, t *asynq.Task) (err error) {
 	taskID := t.ResultWriter().TaskID()
 	taskType := t.Type()
 	jobName := strings.ReplaceAll(taskType, ":", ".")
@@ -52,6 +202,9 @@ func (h *HTTPForwardHandler) ProcessTask(ctx context.Context, t *asynq.Task) err
 			slog.String("job.name", jobName),
 			slog.String("job.id", taskID),
 		)
+		if status != "skipped" {
+			h.publishStart(ctx, taskID)
+		}
 	}
 	defer func() {
 		if !started {
@@ -68,6 +221,11 @@ func (h *HTTPForwardHandler) ProcessTask(ctx context.Context, t *asynq.Task) err
 		}
 		slog.LogAttrs(ctx, slog.LevelInfo, "job finished", attrs...)
 	}()
+	defer func() {
+		if status != "skipped" {
+			h.recordOutcome(ctx, taskID, httpStatus, err)
+		}
+	}()
 
 	payload, err := queue.UnmarshalTaskPayload(t.Payload())
 	if err != nil {
@@ -83,7 +241,114 @@ func (h *HTTPForwardHandler) ProcessTask(ctx context.Context, t *asynq.Task) err
 		return fmt.Errorf("unmarshal payload: %w: %w", err, asynq.SkipRetry)
 	}
 
-	// Extract trace context from task headers (restore as remote parent)
+	// Follow-up tasks and the workflow engine learn the final outcome of
+	// every attempt, including the failures before the request is sent.
+	// They run inside the process span when one was started, and end it
+	queueName, _ := asynq.GetQueueName(ctx)
+	var (
+		span     trace.Span
+		respBody []byte
+	)
+	defer func() {
+		if status != "skipped" {
+			h.enqueueFollowUp(ctx, payload, queueName, taskID, httpStatus, respBody, err)
+			h.completeWorkflowNode(ctx, payload, taskID, err)
+		}
+		if span != nil {
+			span.End()
+		}
+	}()
+
+	if payload.WorkflowID != "" && h.workflows != nil {
+		active, err := h.workflows.Active(ctx, payload.WorkflowID, payload.WorkflowNode, taskID)
+		if err != nil {
+			status = "fail"
+			logStart()
+			slog.ErrorContext(ctx, "job failed",
+				slog.String("event", "job.fail"),
+				slog.String("job.name", jobName),
+				slog.String("job.id", taskID),
+				slog.String("error", err.Error()),
+				slog.String("reason", "workflow_lookup_error"),
+			)
+			return fmt.Errorf("look up workflow: %w", err)
+		}
+		if !active {
+			// The workflow was cancelled or the node retried under a new
+			// task ID in the meantime
+			status = "skipped"
+			return nil
+		}
+	}
+
+	if err := payload.Open(h.keyring, queueName, taskID); err != nil {
+		status = "fail"
+		logStart()
+		slog.ErrorContext(ctx, "job failed",
+			slog.String("event", "job.fail"),
+			slog.String("job.name", jobName),
+			slog.String("job.id", taskID),
+			slog.String("error", err.Error()),
+			slog.String("reason", "decrypt_error"),
+		)
+		return fmt.Errorf("decrypt payload: %w: %w", err, asynq.SkipRetry)
+	}
+
+	if err := payload.Load(ctx, h.store, h.keyring); err != nil {
+		status = "fail"
+		logStart()
+
+		// A missing blob or store will not appear on retry
+		missing := errors.Is(err, blobstore.ErrNotFound) ||
+			errors.Is(err, queue.ErrPayloadStoreMissing) ||
+			errors.Is(err, queue.ErrPayloadSealed)
+		reason := "payload_fetch_error"
+		if missing {
+			reason = "payload_missing"
+		}
+		slog.ErrorContext(ctx, "job failed",
+			slog.String("event", "job.fail"),
+			slog.String("job.name", jobName),
+			slog.String("job.id", taskID),
+			slog.String("error", err.Error()),
+			slog.String("reason", reason),
+		)
+		if missing {
+			return fmt.Errorf("load payload body: %w: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("load payload body: %w", err)
+	}
+
+	// The body is deleted by the collector once the task is done
+	if h.followUps != nil {
+		if err := h.followUps.TrackBody(ctx, queueName, taskID, payload); err != nil {
+			slog.WarnContext(ctx, "failed to track offloaded body",
+				slog.String("job.name", jobName),
+				slog.String("job.id", taskID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	// Notifications are signed over the decompressed body
+	bodyEncoding := ""
+	if h.forwardCompressed && !webhook.IsNotification(taskID) {
+		bodyEncoding = payload.BodyEncoding
+	} else if err := payload.Decompress(); err != nil {
+		status = "fail"
+		logStart()
+		slog.ErrorContext(ctx, "job failed",
+			slog.String("event", "job.fail"),
+			slog.String("job.name", jobName),
+			slog.String("job.id", taskID),
+			slog.String("error", err.Error()),
+			slog.String("reason", "decompress_error"),
+		)
+		return fmt.Errorf("decompress payload body: %w: %w", err, asynq.SkipRetry)
+	}
+
+	// Extract trace context from task headers (restored as remote parent or
+	// used as a span link depending on linkMode)
 	ctx = tracing.ExtractFromMap(ctx, payload.Headers)
 
 	// Extract x-request-id from task headers
@@ -99,16 +364,21 @@ func (h *HTTPForwardHandler) ProcessTask(ctx context.Context, t *asynq.Task) err
 	}
 
 	tracer := otel.Tracer("github.com/KasumiMercury/primind-tasks/internal/worker")
-	ctx, span := tracer.Start(ctx, "task.process")
+	ctx, span = tracer.Start(ctx, "task.process",
+		tracing.ProcessSpanStartOptions(ctx, h.linkMode, queueName, taskID)...)
 	span.SetAttributes(
 		attribute.String("job.name", jobName),
 		attribute.String("task.type", taskType),
 	)
-	defer span.End()
 
 	logStart()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.targetEndpoint, bytes.NewReader(payload.Body))
+	target, client := h.targetEndpoint, h.httpClient
+	if payload.URL != "" {
+		target, client = payload.URL, h.urlClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload.Body))
 	if err != nil {
 		status = "fail"
 		slog.ErrorContext(ctx, "job failed",
@@ -124,13 +394,46 @@ func (h *HTTPForwardHandler) ProcessTask(ctx context.Context, t *asynq.Task) err
 	for k, v := range payload.Headers {
 		req.Header.Set(k, v)
 	}
+	if bodyEncoding != "" {
+		req.Header.Set("Content-Encoding", bodyEncoding)
+	}
+
+	if err := h.webhooks.SignRequest(taskID, target, req, payload.Body); err != nil {
+		status = "fail"
+		slog.ErrorContext(ctx, "job failed",
+			slog.String("event", "job.fail"),
+			slog.String("job.name", jobName),
+			slog.String("job.id", taskID),
+			slog.String("error", err.Error()),
+			slog.String("reason", "webhook_sign_error"),
+		)
+		return fmt.Errorf("sign webhook notification: %w: %w", err, asynq.SkipRetry)
+	}
 
 	// Inject trace context into outgoing request
 	tracing.InjectToHTTPRequest(ctx, req)
 
-	resp, err := h.httpClient.Do(req)
+	dispatched := time.Now()
+	resp, err := client.Do(req)
 	if err != nil {
 		status = "fail"
+		h.recordAttempt(ctx, t.ResultWriter(), dispatched, nil, nil, err)
+
+		var destErr *DestinationError
+		if errors.As(err, &destErr) {
+			slog.ErrorContext(ctx, "job failed",
+				slog.String("event", "job.fail"),
+				slog.String("job.name", jobName),
+				slog.String("job.id", taskID),
+				slog.String("error", err.Error()),
+				slog.String("reason", "destination_denied"),
+				slog.String("destination.host", destErr.Host),
+				slog.String("destination.ip", destErr.IP),
+				slog.String("destination.reason", destErr.Reason),
+			)
+			return fmt.Errorf("destination denied: %w: %w", destErr, asynq.SkipRetry)
+		}
+
 		slog.ErrorContext(ctx, "job failed",
 			slog.String("event", "job.fail"),
 			slog.String("job.name", jobName),
@@ -152,8 +455,17 @@ func (h *HTTPForwardHandler) ProcessTask(ctx context.Context, t *asynq.Task) err
 	httpStatus = resp.StatusCode
 
 	body, _ := io.ReadAll(resp.Body)
+	respBody = body
+	h.recordAttempt(ctx, t.ResultWriter(), dispatched, resp, body, nil)
 
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := h.labels.Remove(ctx, queueName, taskID, payload.Labels); err != nil {
+			slog.WarnContext(ctx, "failed to remove task from label index",
+				slog.String("job.name", jobName),
+				slog.String("job.id", taskID),
+				slog.String("error", err.Error()),
+			)
+		}
 		return nil
 	}
 
@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/KasumiMercury/primind-tasks/internal/events"
+	taskqueuev1 "github.com/KasumiMercury/primind-tasks/internal/gen/taskqueue/v1"
+	"github.com/KasumiMercury/primind-tasks/internal/history"
+)
+
+// recordAttempt stores the outcome of a dispatch as the task result and
+// appends it to the attempt history and the history database, so it can be
+// inspected through the API after the fact. resp is nil when no response was
+// received. Failures are logged and otherwise ignored.
+func (h *HTTPForwardHandler) recordAttempt(ctx context.Context, w *asynq.ResultWriter, dispatched time.Time, resp *http.Response, body []byte, dispatchErr error) {
+	now := time.Now()
+	retried, _ := asynq.GetRetryCount(ctx)
+	attempt := &taskqueuev1.Attempt{
+		DispatchTime:  dispatched.Format(time.RFC3339),
+		ResponseTime:  now.Format(time.RFC3339),
+		DurationMs:    now.Sub(dispatched).Milliseconds(),
+		Reason:        attemptReason(resp, dispatchErr),
+		Worker:        h.instance,
+		AttemptNumber: int32(retried + 1),
+	}
+	if dispatchErr != nil {
+		attempt.Error = dispatchErr.Error()
+	}
+
+	if resp != nil {
+		attempt.ResponseStatus = int32(resp.StatusCode)
+
+		for _, name := range h.recordHeaders {
+			v := resp.Header.Get(name)
+			if v == "" {
+				continue
+			}
+			if attempt.ResponseHeaders == nil {
+				attempt.ResponseHeaders = map[string]string{}
+			}
+			attempt.ResponseHeaders[http.CanonicalHeaderKey(name)] = v
+		}
+
+		if len(body) > h.recordBodyLimit {
+			body = body[:h.recordBodyLimit]
+			attempt.ResponseBodyTruncated = true
+		}
+		if len(body) > 0 {
+			attempt.ResponseBody = body
+		}
+	}
+
+	data, err := proto.Marshal(attempt)
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to record task attempt",
+			slog.String("event", "job.result.fail"),
+			slog.String("job.id", w.TaskID()),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	queueName, _ := asynq.GetQueueName(ctx)
+	h.history.Record(ctx, history.Event{
+		TaskID:         w.TaskID(),
+		Queue:          queueName,
+		Type:           history.EventDispatched,
+		OccurredAt:     dispatched,
+		ResponseStatus: int(attempt.ResponseStatus),
+		Reason:         attempt.Reason,
+		Error:          attempt.Error,
+		AttemptNumber:  int(attempt.AttemptNumber),
+		Worker:         h.instance,
+	})
+
+	if h.attempts == nil {
+		return
+	}
+
+	// The history keeps one entry per attempt, so it leaves out the
+	// response headers and body
+	entry := proto.Clone(attempt).(*taskqueuev1.Attempt)
+	entry.ResponseHeaders = nil
+	entry.ResponseBody = nil
+	entry.ResponseBodyTruncated = false
+
+	if err := h.attempts.Append(ctx, queueName, w.TaskID(), entry); err != nil {
+		slog.WarnContext(ctx, "failed to record task attempt history",
+			slog.String("event", "job.attempt.fail"),
+			slog.String("job.id", w.TaskID()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// recordOutcome records that a task succeeded or finally failed and
+// publishes the outcome; attempts that will be retried are only recorded as
+// dispatches in the history.
+func (h *HTTPForwardHandler) recordOutcome(ctx context.Context, taskID string, httpStatus int, taskErr error) {
+	queueName, _ := asynq.GetQueueName(ctx)
+	retried, _ := asynq.GetRetryCount(ctx)
+	ev := history.Event{
+		TaskID:        taskID,
+		Queue:         queueName,
+		Type:          history.EventSucceeded,
+		AttemptNumber: retried + 1,
+		Worker:        h.instance,
+	}
+	published := events.Event{
+		Type:           events.TypeSucceeded,
+		TaskID:         taskID,
+		Queue:          queueName,
+		AttemptNumber:  retried + 1,
+		ResponseStatus: httpStatus,
+		Worker:         h.instance,
+	}
+
+	if taskErr != nil {
+		published.Type = events.TypeFailed
+		published.Error = taskErr.Error()
+
+		if !isFinalAttempt(ctx, taskErr) {
+			published.Type = events.TypeRetried
+			h.events.Publish(ctx, published)
+			return
+		}
+		ev.Type = history.EventFailed
+		ev.Error = taskErr.Error()
+	}
+
+	h.history.Record(ctx, ev)
+	h.events.Publish(ctx, published)
+}
+
+// publishStart publishes that this worker started processing a task.
+func (h *HTTPForwardHandler) publishStart(ctx context.Context, taskID string) {
+	queueName, _ := asynq.GetQueueName(ctx)
+	retried, _ := asynq.GetRetryCount(ctx)
+	h.events.Publish(ctx, events.Event{
+		Type:          events.TypeStarted,
+		TaskID:        taskID,
+		Queue:         queueName,
+		AttemptNumber: retried + 1,
+		Worker:        h.instance,
+	})
+}
+
+// attemptReason classifies a dispatch like the job.fail log reasons; it is
+// empty on success.
+func attemptReason(resp *http.Response, dispatchErr error) string {
+	if dispatchErr != nil {
+		var destErr *DestinationError
+		if errors.As(dispatchErr, &destErr) {
+			return "destination_denied"
+		}
+		return "http_error"
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return ""
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return "client_error"
+	default:
+		return "server_error"
+	}
+}
+
+// workerInstance identifies this process as hostname:pid, which is the pod
+// name on Kubernetes.
+func workerInstance() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
@@ -2,8 +2,10 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"log"
 	"math"
+	"os"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -12,18 +14,23 @@ import (
 	"github.com/KasumiMercury/primind-tasks/internal/queue"
 )
 
+// ErrServerNotRegistered is returned by Info when this process has not yet
+// published its heartbeat to Redis (or has already stopped).
+var ErrServerNotRegistered = errors.New("worker server is not registered in redis")
+
 type Server struct {
-	server  *asynq.Server
-	handler *HTTPForwardHandler
+	server    *asynq.Server
+	inspector *asynq.Inspector
+	handler   *HTTPForwardHandler
 }
 
-func NewServer(cfg *config.Config) *Server {
+// NewServer creates the worker server. opts are applied to the task handler
+// after the options derived from cfg.
+func NewServer(cfg *config.Config, opts ...HandlerOption) *Server {
+	redisOpt := queue.RedisClientOpt(cfg)
+
 	srv := asynq.NewServer(
-		asynq.RedisClientOpt{
-			Addr:     cfg.RedisAddr,
-			Password: cfg.RedisPassword,
-			DB:       cfg.RedisDB,
-		},
+		redisOpt,
 		asynq.Config{
 			Concurrency: cfg.WorkerConcurrency,
 			Queues: map[string]int{
@@ -40,11 +47,15 @@ func NewServer(cfg *config.Config) *Server {
 		},
 	)
 
-	handler := NewHTTPForwardHandler(cfg.TargetEndpoint, cfg.RequestTimeout)
+	handlerOpts := append([]HandlerOption{
+		WithResponseRecording(cfg.ResponseRecordHeaders, cfg.ResponseRecordBody),
+	}, opts...)
+	handler := NewHTTPForwardHandler(cfg.TargetEndpoint, cfg.RequestTimeout, handlerOpts...)
 
 	return &Server{
-		server:  srv,
-		handler: handler,
+		server:    srv,
+		inspector: asynq.NewInspector(redisOpt),
+		handler:   handler,
 	}
 }
 
@@ -56,4 +67,35 @@ func (s *Server) Run() error {
 
 func (s *Server) Shutdown() {
 	s.server.Shutdown()
+	if err := s.inspector.Close(); err != nil {
+		log.Printf("warning: could not close inspector: %v", err)
+	}
+}
+
+// Ping checks if the Redis connection used by the worker is healthy.
+func (s *Server) Ping() error {
+	return s.server.Ping()
+}
+
+// Info returns the state of this worker process as published in its
+// heartbeat, including concurrency, consumed queues and active tasks.
+func (s *Server) Info() (*asynq.ServerInfo, error) {
+	servers, err := s.inspector.Servers()
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	pid := os.Getpid()
+
+	for _, info := range servers {
+		if info.Host == host && info.PID == pid {
+			return info, nil
+		}
+	}
+
+	return nil, ErrServerNotRegistered
 }
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+// completeWorkflowNode reports the outcome of a workflow node task after a
+// successful or final failed attempt, so the workflow can advance.
+func (h *HTTPForwardHandler) completeWorkflowNode(ctx context.Context, payload *queue.TaskPayload, taskID string, taskErr error) {
+	if payload == nil || payload.WorkflowID == "" {
+		return
+	}
+	if taskErr != nil && !isFinalAttempt(ctx, taskErr) {
+		return
+	}
+
+	if h.workflows == nil {
+		slog.ErrorContext(ctx, "failed to update workflow",
+			slog.String("event", "workflow.node.update.fail"),
+			slog.String("job.id", taskID),
+			slog.String("workflow.id", payload.WorkflowID),
+			slog.String("error", "no workflow engine configured"),
+		)
+		return
+	}
+
+	if err := h.workflows.Complete(ctx, payload.WorkflowID, payload.WorkflowNode, taskID, taskErr); err != nil {
+		slog.ErrorContext(ctx, "failed to update workflow",
+			slog.String("event", "workflow.node.update.fail"),
+			slog.String("job.id", taskID),
+			slog.String("workflow.id", payload.WorkflowID),
+			slog.String("workflow.node", payload.WorkflowNode),
+			slog.String("error", err.Error()),
+		)
+	}
+}
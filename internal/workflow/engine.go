@@ -0,0 +1,250 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/KasumiMercury/primind-tasks/internal/observability/logging"
+	"github.com/KasumiMercury/primind-tasks/internal/observability/tracing"
+	"github.com/KasumiMercury/primind-tasks/internal/queue"
+)
+
+// errStale is returned by update functions for outcomes of task attempts the
+// workflow no longer waits for.
+var errStale = errors.New("stale workflow task")
+
+// Engine submits workflows and advances them as their tasks finish.
+type Engine struct {
+	store  *store
+	client *queue.Client
+}
+
+// NewEngine stores workflows in rdb, keeping finished ones for retention,
+// and enqueues their nodes through client.
+func NewEngine(rdb redis.UniversalClient, client *queue.Client, retention time.Duration) *Engine {
+	return &Engine{
+		store:  &store{rdb: rdb, retention: retention},
+		client: client,
+	}
+}
+
+// Submit validates and stores a new workflow and enqueues its root nodes.
+// Node payloads must already be encoded with queue.Client.EncodePayload.
+func (e *Engine) Submit(ctx context.Context, wf *Workflow) error {
+	if err := wf.Validate(); err != nil {
+		return err
+	}
+
+	for _, n := range wf.Nodes {
+		n.State = NodePending
+		n.Attempt = 0
+		n.TaskID = ""
+		n.Error = ""
+	}
+	wf.State = StateRunning
+	nodes := wf.enqueueReady()
+
+	if err := e.store.create(ctx, wf); err != nil {
+		return err
+	}
+
+	e.enqueue(ctx, wf, nodes)
+
+	return nil
+}
+
+func (e *Engine) Get(ctx context.Context, id string) (*Workflow, error) {
+	return e.store.get(ctx, e.store.rdb, id)
+}
+
+// Active reports whether taskID is the current attempt of a node the
+// workflow still waits for. Tasks of cancelled workflows or superseded
+// attempts should not be processed.
+func (e *Engine) Active(ctx context.Context, id, nodeID, taskID string) (bool, error) {
+	wf, err := e.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	n := wf.Node(nodeID)
+	return n != nil && n.State == NodeEnqueued && n.TaskID == taskID, nil
+}
+
+// Complete records the outcome of a node's task and enqueues the nodes that
+// became ready. taskErr is nil on success and the final error otherwise.
+// Outcomes of superseded attempts are ignored.
+func (e *Engine) Complete(ctx context.Context, id, nodeID, taskID string, taskErr error) error {
+	var nodes []*Node
+
+	wf, err := e.store.update(ctx, id, func(wf *Workflow) error {
+		n := wf.Node(nodeID)
+		if n == nil || n.State != NodeEnqueued || n.TaskID != taskID {
+			return errStale
+		}
+
+		if taskErr == nil {
+			n.State = NodeSucceeded
+		} else {
+			n.State = NodeFailed
+			n.Error = taskErr.Error()
+		}
+		wf.updateState()
+		nodes = wf.enqueueReady()
+
+		return nil
+	})
+	if errors.Is(err, errStale) || errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "workflow node finished",
+		slog.String("event", "workflow.node.finish"),
+		slog.String("workflow.id", id),
+		slog.String("workflow.node", nodeID),
+		slog.String("workflow.state", wf.State),
+		slog.Bool("success", taskErr == nil),
+	)
+
+	e.enqueue(ctx, wf, nodes)
+
+	return nil
+}
+
+// Cancel stops a running or failed workflow: pending nodes are cancelled and
+// the tasks of enqueued nodes are deleted.
+func (e *Engine) Cancel(ctx context.Context, id string) (*Workflow, error) {
+	var cancelled []*Node
+
+	wf, err := e.store.update(ctx, id, func(wf *Workflow) error {
+		cancelled = nil
+		if wf.State != StateRunning && wf.State != StateFailed {
+			return fmt.Errorf("%w: cannot cancel a %s workflow", ErrInvalidState, wf.State)
+		}
+
+		for _, n := range wf.Nodes {
+			switch n.State {
+			case NodeEnqueued:
+				cancelled = append(cancelled, n)
+				n.State = NodeCancelled
+			case NodePending:
+				n.State = NodeCancelled
+			}
+		}
+		wf.State = StateCancelled
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range cancelled {
+		err := e.client.DeleteTaskFromQueue(wf.Queue, n.TaskID)
+		if err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+			// The task is skipped by the worker anyway, since its node is
+			// no longer enqueued
+			slog.WarnContext(ctx, "failed to delete workflow task",
+				slog.String("event", "workflow.cancel.delete.fail"),
+				slog.String("workflow.id", id),
+				slog.String("workflow.node", n.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return wf, nil
+}
+
+// Retry resets the failed and cancelled nodes of a failed or cancelled
+// workflow and enqueues those whose dependencies have succeeded. Succeeded
+// nodes are not run again.
+func (e *Engine) Retry(ctx context.Context, id string) (*Workflow, error) {
+	var nodes []*Node
+
+	wf, err := e.store.update(ctx, id, func(wf *Workflow) error {
+		if wf.State != StateFailed && wf.State != StateCancelled {
+			return fmt.Errorf("%w: cannot retry a %s workflow", ErrInvalidState, wf.State)
+		}
+
+		for _, n := range wf.Nodes {
+			if n.State == NodeFailed || n.State == NodeCancelled {
+				n.State = NodePending
+			}
+		}
+		wf.State = StateRunning
+		wf.updateState()
+		nodes = wf.enqueueReady()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.enqueue(ctx, wf, nodes)
+
+	return wf, nil
+}
+
+// enqueue enqueues the tasks of nodes already marked as enqueued. A node that
+// cannot be enqueued is recorded as failed.
+func (e *Engine) enqueue(ctx context.Context, wf *Workflow, nodes []*Node) {
+	for _, n := range nodes {
+		err := e.enqueueNode(ctx, wf, n)
+		if err == nil {
+			continue
+		}
+
+		slog.ErrorContext(ctx, "failed to enqueue workflow node",
+			slog.String("event", "workflow.node.enqueue.fail"),
+			slog.String("workflow.id", wf.ID),
+			slog.String("workflow.node", n.ID),
+			slog.String("error", err.Error()),
+		)
+
+		if err := e.Complete(ctx, wf.ID, n.ID, n.TaskID, fmt.Errorf("enqueue task: %w", err)); err != nil {
+			slog.ErrorContext(ctx, "failed to record workflow node failure",
+				slog.String("event", "workflow.node.update.fail"),
+				slog.String("workflow.id", wf.ID),
+				slog.String("workflow.node", n.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+func (e *Engine) enqueueNode(ctx context.Context, wf *Workflow, n *Node) error {
+	payload, err := queue.UnmarshalTaskPayload(n.Payload)
+	if err != nil {
+		return err
+	}
+
+	payload.WorkflowID = wf.ID
+	payload.WorkflowNode = n.ID
+	payload.CreatedAt = time.Now()
+	tracing.InjectToMap(ctx, payload.Headers)
+	reqID := logging.RequestIDFromContext(ctx)
+	if reqID == "" {
+		reqID = logging.ValidateAndExtractRequestID("")
+	}
+	payload.Headers["x-request-id"] = reqID
+
+	_, err = e.client.EnqueueTaskWithQueue(payload, nil, wf.Queue, n.TaskID)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+
+	return err
+}
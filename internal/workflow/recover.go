@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// recoveryGrace is how long a workflow stays untouched before its enqueued
+// nodes are checked, leaving time for enqueues in progress to finish.
+const recoveryGrace = time.Minute
+
+// RunRecovery calls Recover every interval until ctx is cancelled.
+func (e *Engine) RunRecovery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := e.Recover(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to recover workflow nodes",
+				slog.String("event", "workflow.recover.fail"),
+				slog.String("error", err.Error()),
+			)
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "recovered workflow nodes",
+				slog.String("event", "workflow.recover"),
+				slog.Int("workflow.nodes", n),
+			)
+		}
+	}
+}
+
+// Recover enqueues again the tasks of enqueued nodes of running workflows
+// whose task does not exist, as left behind when a process stopped between
+// storing a node and enqueueing its task. It returns the number of nodes
+// enqueued again.
+//
+// Node task IDs are deterministic, so a task enqueued concurrently is not
+// enqueued twice. A node whose task finished but whose outcome could not be
+// recorded runs again.
+func (e *Engine) Recover(ctx context.Context) (int, error) {
+	recovered := 0
+
+	iter := e.store.rdb.Scan(ctx, 0, keyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		wf, err := e.Get(ctx, iter.Val()[len(keyPrefix):])
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return recovered, err
+		}
+		if wf.State != StateRunning || time.Since(wf.UpdatedAt) < recoveryGrace {
+			continue
+		}
+
+		var stranded []*Node
+		for _, n := range wf.Nodes {
+			if n.State != NodeEnqueued {
+				continue
+			}
+			_, err := e.client.GetTask(wf.Queue, n.TaskID)
+			if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+				stranded = append(stranded, n)
+				continue
+			}
+			if err != nil {
+				return recovered, err
+			}
+		}
+		if len(stranded) == 0 {
+			continue
+		}
+
+		for _, n := range stranded {
+			slog.WarnContext(ctx, "enqueueing stranded workflow node again",
+				slog.String("event", "workflow.node.recover"),
+				slog.String("workflow.id", wf.ID),
+				slog.String("workflow.node", n.ID),
+				slog.String("task_id", n.TaskID),
+			)
+		}
+		e.enqueue(ctx, wf, stranded)
+		recovered += len(stranded)
+	}
+
+	return recovered, iter.Err()
+}
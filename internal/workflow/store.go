@@ -0,0 +1,118 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix prefixes the Redis key holding a workflow as JSON.
+const keyPrefix = "primind:workflow:"
+
+// maxUpdateAttempts bounds the optimistic transaction retries of update;
+// concurrent nodes of one workflow finishing at once conflict briefly.
+const maxUpdateAttempts = 10
+
+// store persists workflows in Redis, one key per workflow.
+type store struct {
+	rdb       redis.UniversalClient
+	retention time.Duration
+}
+
+func workflowKey(id string) string {
+	return keyPrefix + id
+}
+
+// expiration keeps running workflows forever and finished ones for the
+// retention period.
+func (s *store) expiration(wf *Workflow) time.Duration {
+	if wf.Finished() {
+		return s.retention
+	}
+
+	return 0
+}
+
+func (s *store) create(ctx context.Context, wf *Workflow) error {
+	data, err := json.Marshal(wf)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.rdb.SetNX(ctx, workflowKey(wf.ID), data, s.expiration(wf)).Result()
+	if err != nil {
+		return err
+	}
+	if !created {
+		return fmt.Errorf("%w: %s", ErrAlreadyExists, wf.ID)
+	}
+
+	return nil
+}
+
+func (s *store) get(ctx context.Context, rdb redis.Cmdable, id string) (*Workflow, error) {
+	data, err := rdb.Get(ctx, workflowKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var wf Workflow
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return nil, err
+	}
+
+	return &wf, nil
+}
+
+// update applies fn to the stored workflow in an optimistic transaction,
+// retrying when another update got in between. An error from fn aborts the
+// update and is returned as is.
+func (s *store) update(ctx context.Context, id string, fn func(*Workflow) error) (*Workflow, error) {
+	key := workflowKey(id)
+
+	for range maxUpdateAttempts {
+		var wf *Workflow
+
+		err := s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			current, err := s.get(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := fn(current); err != nil {
+				return err
+			}
+			current.UpdatedAt = time.Now()
+
+			data, err := json.Marshal(current)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, data, s.expiration(current))
+				return nil
+			})
+			wf = current
+
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return wf, nil
+	}
+
+	return nil, fmt.Errorf("update workflow %s: too many concurrent updates", id)
+}
@@ -0,0 +1,208 @@
+// Package workflow runs DAGs of dependent tasks, enqueueing each node once
+// the nodes it depends on have succeeded.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Workflow states.
+const (
+	StateRunning   = "RUNNING"
+	StateSucceeded = "SUCCEEDED"
+	StateFailed    = "FAILED"
+	StateCancelled = "CANCELLED"
+)
+
+// Node states.
+const (
+	NodePending   = "PENDING"
+	NodeEnqueued  = "ENQUEUED"
+	NodeSucceeded = "SUCCEEDED"
+	NodeFailed    = "FAILED"
+	NodeCancelled = "CANCELLED"
+)
+
+// MaxNodes bounds the size of a workflow, which is stored as a single value.
+const MaxNodes = 100
+
+var (
+	ErrNotFound      = errors.New("workflow not found")
+	ErrAlreadyExists = errors.New("workflow already exists")
+	// ErrInvalidState is returned when a workflow cannot be cancelled or
+	// retried in its current state
+	ErrInvalidState = errors.New("invalid workflow state")
+)
+
+// Workflow is a DAG of task templates together with the state of each node.
+type Workflow struct {
+	ID        string    `json:"id"`
+	Queue     string    `json:"queue"`
+	Nodes     []*Node   `json:"nodes"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Node is a task template enqueued once all DependsOn nodes have succeeded.
+type Node struct {
+	ID        string   `json:"id"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Payload is the encoded queue.TaskPayload enqueued for the node
+	Payload []byte `json:"payload"`
+	State   string `json:"state"`
+	// Attempt counts how often the node was enqueued; retried nodes get a
+	// new task ID per attempt
+	Attempt int    `json:"attempt"`
+	TaskID  string `json:"task_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Validate checks that node IDs are unique, dependencies exist and the graph
+// has no cycles.
+func (w *Workflow) Validate() error {
+	if len(w.Nodes) == 0 {
+		return errors.New("workflow has no nodes")
+	}
+	if len(w.Nodes) > MaxNodes {
+		return fmt.Errorf("workflow has %d nodes, the limit is %d", len(w.Nodes), MaxNodes)
+	}
+
+	ids := make(map[string]bool, len(w.Nodes))
+	for _, n := range w.Nodes {
+		if ids[n.ID] {
+			return fmt.Errorf("duplicate node %q", n.ID)
+		}
+		ids[n.ID] = true
+	}
+
+	// Kahn's algorithm: every node must be reachable by removing nodes
+	// without remaining dependencies
+	remaining := make(map[string]int, len(w.Nodes))
+	dependents := make(map[string][]string, len(w.Nodes))
+	for _, n := range w.Nodes {
+		for _, dep := range n.DependsOn {
+			if !ids[dep] {
+				return fmt.Errorf("node %q depends on unknown node %q", n.ID, dep)
+			}
+			if dep == n.ID {
+				return fmt.Errorf("node %q depends on itself", n.ID)
+			}
+			dependents[dep] = append(dependents[dep], n.ID)
+		}
+		remaining[n.ID] = len(n.DependsOn)
+	}
+
+	var ready []string
+	for _, n := range w.Nodes {
+		if remaining[n.ID] == 0 {
+			ready = append(ready, n.ID)
+		}
+	}
+
+	visited := 0
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		visited++
+
+		for _, next := range dependents[id] {
+			remaining[next]--
+			if remaining[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+	if visited != len(w.Nodes) {
+		return errors.New("workflow dependencies contain a cycle")
+	}
+
+	return nil
+}
+
+// Node returns the node with the given ID, or nil.
+func (w *Workflow) Node(id string) *Node {
+	for _, n := range w.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// Finished reports whether the workflow is in a terminal state.
+func (w *Workflow) Finished() bool {
+	return w.State == StateSucceeded || w.State == StateCancelled ||
+		(w.State == StateFailed && !w.hasNodeIn(NodeEnqueued))
+}
+
+// ready returns the pending nodes whose dependencies have all succeeded.
+// Nothing is ready unless the workflow is running.
+func (w *Workflow) ready() []*Node {
+	if w.State != StateRunning {
+		return nil
+	}
+
+	var nodes []*Node
+	for _, n := range w.Nodes {
+		if n.State != NodePending {
+			continue
+		}
+
+		satisfied := true
+		for _, dep := range n.DependsOn {
+			if d := w.Node(dep); d == nil || d.State != NodeSucceeded {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
+
+// updateState derives the workflow state from its nodes. Cancelled
+// workflows stay cancelled until retried.
+func (w *Workflow) updateState() {
+	switch {
+	case w.State == StateCancelled:
+	case w.hasNodeIn(NodeFailed):
+		w.State = StateFailed
+	case !w.hasNodeIn(NodePending, NodeEnqueued):
+		w.State = StateSucceeded
+	default:
+		w.State = StateRunning
+	}
+}
+
+func (w *Workflow) hasNodeIn(states ...string) bool {
+	for _, n := range w.Nodes {
+		for _, s := range states {
+			if n.State == s {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// enqueueReady marks the ready nodes as enqueued with a new task ID and
+// returns them.
+func (w *Workflow) enqueueReady() []*Node {
+	nodes := w.ready()
+	for _, n := range nodes {
+		n.Attempt++
+		n.TaskID = fmt.Sprintf("%s:%s:%d", w.ID, n.ID, n.Attempt)
+		n.State = NodeEnqueued
+		n.Error = ""
+	}
+
+	return nodes
+}